@@ -3,19 +3,27 @@ package api
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/db/util"
 	"simplebank/token"
 )
 
+var (
+	errAccountNotEmpty  = errors.New("Only accounts with a zero balance can be closed")
+	errAccountChanged   = errors.New("The account was changed by another request, please retry")
+	errNoAccountChanges = errors.New("At least one of location or nickname must be provided")
+	errSystemAccount    = errors.New("The status of a system account cannot be changed")
+)
+
+// Only metadata can be edited, balances change through ledgered operations
+// and fields left out of the request keep their current value
 type updateAccountRequest struct {
-	Id       int64  `json:"id" binding:"required"`
-	Username string `json:"username" binding:"required"`
-	Balance  int64  `json:"balance"  binding:"required"`
-	Currency string `json:"currency" binding:"required"`
-	Location string `json:"location" binding:"required"`
+	Location *string `json:"location" binding:"omitempty,min=1,max=100"`
+	Nickname *string `json:"nickname" binding:"omitempty,max=50"`
 }
 
 type createAccountRequest struct {
@@ -27,6 +35,10 @@ type accountByIdRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+type updateOverdraftLimitRequest struct {
+	OverdraftLimit *int64 `json:"overdraft_limit" binding:"required,min=0"`
+}
+
 type listAccountRequest struct {
 	PageID   int32 `form:"page_id" binding:"required,min=1"`
 	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
@@ -49,7 +61,8 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	if account.Username != ctx.MustGet(authPayLoadKey).(*token.Payload).Username {
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	if account.Username != authPayload.Username && !isStaff(authPayload.Role) {
 		err := errors.New("The account does not belong to the user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
@@ -113,21 +126,44 @@ func (server *Server) createAccount(ctx *gin.Context) {
 }
 
 func (server *Server) updateAccount(ctx *gin.Context) {
+	var uri accountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	var req updateAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	arg := db.UpdateAccountParams{
-		ID:       req.Id,
-		Username: req.Username,
-		Balance:  req.Balance,
-		Currency: req.Currency,
-		Location: req.Location,
+	if req.Location == nil && req.Nickname == nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errNoAccountChanges))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if account.Username != ctx.MustGet(authPayLoadKey).(*token.Payload).Username {
+		err := errors.New("The account does not belong to the user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
 	}
 
-	account, err := server.store.UpdateAccount(ctx, arg)
+	account, err = server.store.UpdateAccount(ctx, db.UpdateAccountParams{
+		ID:       account.ID,
+		Location: nullString(req.Location),
+		Nickname: nullString(req.Nickname),
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -136,14 +172,57 @@ func (server *Server) updateAccount(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, account)
 }
 
-func (server *Server) deleteAccount(ctx *gin.Context) {
+// Only staff can extend credit, the limit is checked on every transfer
+func (server *Server) updateOverdraftLimit(ctx *gin.Context) {
+	var uri accountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateOverdraftLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.UpdateAccountOverdraftLimit(ctx, db.UpdateAccountOverdraftLimitParams{
+		ID:             uri.ID,
+		OverdraftLimit: *req.OverdraftLimit,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, util.FrozenAccountStatus)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, util.ActiveAccountStatus)
+}
+
+// Accounts are closed rather than deleted so that their history is kept
+func (server *Server) closeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, util.ClosedAccountStatus)
+}
+
+func (server *Server) changeAccountStatus(ctx *gin.Context, status string) {
 	var req accountByIdRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	err := server.store.DeleteAccount(ctx, req.ID)
+	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
@@ -153,5 +232,50 @@ func (server *Server) deleteAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, "Delete Successed.")
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	if account.Username != authPayload.Username && !isStaff(authPayload.Role) {
+		err := errors.New("The account does not belong to the user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// Every deposit, withdrawal and conversion in the currency goes through them
+	if util.IsSystemAccount(account.Kind) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errSystemAccount))
+		return
+	}
+
+	if !util.CanChangeAccountStatus(account.Status, status) {
+		err := fmt.Errorf("account [%d] cannot change from %s to %s", account.ID, account.Status, status)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	if status == util.ClosedAccountStatus {
+		if account.Balance != 0 {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errAccountNotEmpty))
+			return
+		}
+		account, err = server.store.CloseAccount(ctx, db.CloseAccountParams{
+			ID:         account.ID,
+			FromStatus: account.Status,
+		})
+	} else {
+		account, err = server.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{
+			ID:         account.ID,
+			Status:     status,
+			FromStatus: account.Status,
+		})
+	}
+	if err != nil {
+		// The status or balance changed since the account was read
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errAccountChanged))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
 }
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/token"
+	"strings"
+	"time"
+)
+
+const (
+	// API keys look like sbk_<prefix>_<secret>
+	apiKeyTag = "sbk"
+	// Random bytes in the public prefix that identifies a key
+	apiKeyPrefixSize = 4
+	// Entropy of the secret part of a key in bytes
+	apiKeySecretSize = 32
+)
+
+var (
+	errInvalidAPIKey  = errors.New("Invalid API key")
+	errAPIKeyRevoked  = errors.New("API key has been revoked")
+	errAPIKeyExpired  = errors.New("API key has expired")
+	errAPIKeyNotFound = errors.New("API key not found or already revoked")
+)
+
+type createAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,scope"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+type revokeAPIKeyRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type apiKeyResponse struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type createAPIKeyResponse struct {
+	// Only ever returned here, the server keeps a hash of it
+	Key    string         `json:"key"`
+	APIKey apiKeyResponse `json:"api_key"`
+}
+
+func newAPIKeyResponse(apiKey db.ApiKey) apiKeyResponse {
+	response := apiKeyResponse{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		ExpiresAt: apiKey.ExpiresAt,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if apiKey.RevokedAt.Valid {
+		response.RevokedAt = &apiKey.RevokedAt.Time
+	}
+	return response
+}
+
+func (server *Server) createAPIKey(ctx *gin.Context) {
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	apiKey, err := server.store.CreateApiKey(ctx, db.CreateApiKeyParams{
+		Username:  authPayload.Username,
+		Name:      req.Name,
+		Prefix:    prefix,
+		HashedKey: util.HashToken(key),
+		Scopes:    req.Scopes,
+		ExpiresAt: time.Now().AddDate(0, 0, req.ExpiresInDays),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, createAPIKeyResponse{
+		Key:    key,
+		APIKey: newAPIKeyResponse(apiKey),
+	})
+}
+
+func (server *Server) listAPIKeys(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	apiKeys, err := server.store.ListApiKeys(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := make([]apiKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		response[i] = newAPIKeyResponse(apiKey)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (server *Server) revokeAPIKey(ctx *gin.Context) {
+	var req revokeAPIKeyRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	apiKey, err := server.store.RevokeApiKey(ctx, db.RevokeApiKeyParams{
+		ID:       req.ID,
+		Username: authPayload.Username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errAPIKeyNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAPIKeyResponse(apiKey))
+}
+
+// Returns a new key along with the prefix it is looked up by
+func generateAPIKey() (string, string, error) {
+	prefixBytes := make([]byte, apiKeyPrefixSize)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", err
+	}
+	prefix := hex.EncodeToString(prefixBytes)
+
+	secret, err := util.GenerateSecureToken(apiKeySecretSize)
+	if err != nil {
+		return "", "", err
+	}
+
+	return apiKeyTag + "_" + prefix + "_" + secret, prefix, nil
+}
+
+// apiKeyAuthenticator resolves API keys to the user that owns them. Keys act
+// with the current role of their owner but only within their scopes.
+type apiKeyAuthenticator struct {
+	store db.Store
+}
+
+func newAPIKeyAuthenticator(store db.Store) *apiKeyAuthenticator {
+	return &apiKeyAuthenticator{store: store}
+}
+
+// Returns a payload for the owner of the key and the scopes granted to it
+func (auth *apiKeyAuthenticator) authenticate(ctx context.Context, key string) (*token.Payload, []string, error) {
+	fields := strings.SplitN(key, "_", 3)
+	if len(fields) != 3 || fields[0] != apiKeyTag || fields[1] == "" {
+		return nil, nil, errInvalidAPIKey
+	}
+
+	apiKey, err := auth.store.GetApiKeyByPrefix(ctx, fields[1])
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, errInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(key)), []byte(apiKey.HashedKey)) != 1 {
+		return nil, nil, errInvalidAPIKey
+	}
+	if apiKey.RevokedAt.Valid {
+		return nil, nil, errAPIKeyRevoked
+	}
+	if time.Now().After(apiKey.ExpiresAt) {
+		return nil, nil, errAPIKeyExpired
+	}
+
+	payload := &token.Payload{
+		ID:        uuid.Nil,
+		Username:  apiKey.Username,
+		Role:      apiKey.Role,
+		IssuedAt:  time.Now(),
+		ExpiredAt: apiKey.ExpiresAt,
+	}
+	return payload, apiKey.Scopes, nil
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	db "simplebank/db/sqlc"
+)
+
+type cashRequest struct {
+	Amount   int64  `json:"amount" binding:"required,gt=0"`
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+func (server *Server) depositCash(ctx *gin.Context) {
+	server.moveCash(ctx, server.store.DepositTx)
+}
+
+func (server *Server) withdrawCash(ctx *gin.Context) {
+	server.moveCash(ctx, server.store.WithdrawTx)
+}
+
+// Books cash handed over a teller's desk against the bank's cash account
+func (server *Server) moveCash(ctx *gin.Context, cashTx func(context.Context, db.CashTxParams) (db.TransactionTxResult, error)) {
+	var uri accountByIdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req cashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	validAccount, _ := server.validAccount(ctx, uri.ID, req.Currency)
+	if !validAccount {
+		return
+	}
+
+	result, err := cashTx(ctx, db.CashTxParams{
+		AccountID: uri.ID,
+		Amount:    req.Amount,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrAccountCannotTransfer) || errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrNoCashAccount) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"testing"
+	"time"
+)
+
+func TestCreateExchangeRateAPI(t *testing.T) {
+	rate := db.ExchangeRate{
+		ID:           util.RandomInt(1, 1000),
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		Rate:         "0.9200000000",
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"from_currency": util.USD, "to_currency": util.EUR, "rate": "0.92"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateExchangeRateParams{
+					FromCurrency: util.USD,
+					ToCurrency:   util.EUR,
+					Rate:         "0.92",
+				}
+				store.EXPECT().CreateExchangeRate(gomock.Any(), gomock.Eq(arg)).Times(1).Return(rate, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := ioutil.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var gotRate db.ExchangeRate
+				err = json.Unmarshal(data, &gotRate)
+				require.NoError(t, err)
+				require.Equal(t, rate, gotRate)
+			},
+		},
+		{
+			name: "Same Currency",
+			body: gin.H{"from_currency": util.USD, "to_currency": util.USD, "rate": "1"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Rate",
+			body: gin.H{"from_currency": util.USD, "to_currency": util.EUR, "rate": "-0.92"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Fraction Rate",
+			body: gin.H{"from_currency": util.USD, "to_currency": util.EUR, "rate": "1/3"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Teller Forbidden",
+			body: gin.H{"from_currency": util.USD, "to_currency": util.EUR, "rate": "0.92"},
+			role: util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Internal Error",
+			body: gin.H{"from_currency": util.USD, "to_currency": util.EUR, "rate": "0.92"},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRate(gomock.Any(), gomock.Any()).Times(1).Return(db.ExchangeRate{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(currTest.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/exchange_rates", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, "admin", currTest.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/token"
+	"strings"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader           = "Idempotency-Key"
+	idempotentReplayedHeader       = "Idempotent-Replayed"
+	maxIdempotencyKeyLength        = 255
+	maxIdempotentBodyBytes         = 1 << 20
+	defaultIdempotencyKeyRetention = 24 * time.Hour
+)
+
+var (
+	errInvalidIdempotencyKey = errors.New("Idempotency-Key must be at most 255 characters long")
+	errIdempotencyKeyReused  = errors.New("Idempotency-Key was already used for a different request")
+	errIdempotencyKeyInUse   = errors.New("A request with this Idempotency-Key is still being processed")
+	errIdempotentBodyTooBig  = errors.New("Requests with an Idempotency-Key must have a body of at most 1 MiB")
+)
+
+// Keeps a copy of the response so that it can be stored for replays
+type idempotencyResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (writer *idempotencyResponseWriter) Write(data []byte) (int, error) {
+	writer.body.Write(data)
+	return writer.ResponseWriter.Write(data)
+}
+
+func (writer *idempotencyResponseWriter) WriteString(data string) (int, error) {
+	writer.body.WriteString(data)
+	return writer.ResponseWriter.WriteString(data)
+}
+
+// Makes requests carrying an Idempotency-Key safe to retry. The first request
+// with a key runs and its response is stored, retries with the same key and
+// body get the stored response back. Keys are per user and expire after the
+// retention period. Requests without the header are not affected.
+func idempotencyMiddleware(store db.Store, retention time.Duration) gin.HandlerFunc {
+	if retention <= 0 {
+		retention = defaultIdempotencyKeyRetention
+	}
+
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(errInvalidIdempotencyKey))
+			return
+		}
+
+		// The whole body is held in memory to fingerprint it
+		body, err := ioutil.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			// MaxBytesReader stops at the limit, a shorter body failed for another reason
+			if len(body) == maxIdempotentBodyBytes {
+				ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, errorResponse(errIdempotentBodyTooBig))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		username := ctx.MustGet(authPayLoadKey).(*token.Payload).Username
+		fingerprint := requestFingerprint(ctx.Request.Method, ctx.Request.URL.Path, ctx.GetHeader("Content-Type"), body)
+
+		_, err = store.ClaimIdempotencyKey(ctx, db.ClaimIdempotencyKeyParams{
+			Username:    username,
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   time.Now().Add(retention),
+		})
+		if err != nil {
+			// The key is taken and has not expired yet
+			if err == sql.ErrNoRows {
+				replayIdempotentResponse(ctx, store, username, key, fingerprint)
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		// A panicking handler never writes its 500 here, the recovery middleware does.
+		// Release the key like for any other server error, or it stays in use until it expires.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				releaseIdempotencyKey(ctx, store, username, key)
+				panic(recovered)
+			}
+		}()
+
+		writer := &idempotencyResponseWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		ctx.Next()
+
+		// Nothing was done, so the client may try again with the same key
+		if writer.Status() >= http.StatusInternalServerError {
+			releaseIdempotencyKey(ctx, store, username, key)
+			return
+		}
+
+		err = store.SaveIdempotencyResponse(ctx, db.SaveIdempotencyResponseParams{
+			Username:     username,
+			Key:          key,
+			StatusCode:   sql.NullInt32{Int32: int32(writer.Status()), Valid: true},
+			ResponseBody: writer.body.Bytes(),
+		})
+		if err != nil {
+			log.Printf("cannot save idempotent response of %s: %v", username, err)
+		}
+	}
+}
+
+func releaseIdempotencyKey(ctx *gin.Context, store db.Store, username string, key string) {
+	err := store.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{
+		Username: username,
+		Key:      key,
+	})
+	if err != nil {
+		log.Printf("cannot release idempotency key of %s: %v", username, err)
+	}
+}
+
+func replayIdempotentResponse(ctx *gin.Context, store db.Store, username string, key string, fingerprint string) {
+	idempotencyKey, err := store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: username,
+		Key:      key,
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if idempotencyKey.Fingerprint != fingerprint {
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, errorResponse(errIdempotencyKeyReused))
+		return
+	}
+	if !idempotencyKey.StatusCode.Valid {
+		ctx.AbortWithStatusJSON(http.StatusConflict, errorResponse(errIdempotencyKeyInUse))
+		return
+	}
+
+	ctx.Header(idempotentReplayedHeader, "true")
+	ctx.Data(int(idempotencyKey.StatusCode.Int32), "application/json; charset=utf-8", idempotencyKey.ResponseBody)
+	ctx.Abort()
+}
+
+// Identifies a request by what it does, so a key cannot be reused for another.
+// Clients pick a new random boundary for every multipart body, so it is left
+// out for a retry of an upload to match.
+func requestFingerprint(method string, path string, contentType string, body []byte) string {
+	request := method + " " + path + "\n"
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err == nil && strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		request += mediaType + "\n"
+		body = bytes.ReplaceAll(body, []byte(params["boundary"]), nil)
+	}
+
+	return util.HashToken(request + string(body))
+}
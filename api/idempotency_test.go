@@ -0,0 +1,290 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	key := "7f4c2a52-5a9f-4bde-9f34-0c6e7a3c9d10"
+	body := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          10,
+		"currency":        util.USD,
+	}
+
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	fingerprint := requestFingerprint(http.MethodPost, "/transactions", "application/json", data)
+
+	storedResponse := []byte(`{"transaction":{"id":42}}`)
+
+	testCases := []struct {
+		name          string
+		key           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "No Key",
+			key:  "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().SaveIdempotencyResponse(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "First Request",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ClaimIdempotencyKeyParams) (db.IdempotencyKey, error) {
+						require.Equal(t, user1.Username, arg.Username)
+						require.Equal(t, key, arg.Key)
+						require.Equal(t, fingerprint, arg.Fingerprint)
+						require.WithinDuration(t, time.Now().Add(defaultIdempotencyKeyRetention), arg.ExpiresAt, time.Minute)
+						return db.IdempotencyKey{Username: arg.Username, Key: arg.Key, Fingerprint: arg.Fingerprint}, nil
+					})
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().SaveIdempotencyResponse(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.SaveIdempotencyResponseParams) error {
+						require.Equal(t, user1.Username, arg.Username)
+						require.Equal(t, key, arg.Key)
+						require.Equal(t, sql.NullInt32{Int32: http.StatusOK, Valid: true}, arg.StatusCode)
+						require.NotEmpty(t, arg.ResponseBody)
+						return nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name: "Replay",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+
+				arg := db.GetIdempotencyKeyParams{Username: user1.Username, Key: key}
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.IdempotencyKey{
+					Username:     user1.Username,
+					Key:          key,
+					Fingerprint:  fingerprint,
+					StatusCode:   sql.NullInt32{Int32: http.StatusOK, Valid: true},
+					ResponseBody: storedResponse,
+				}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+				require.Equal(t, storedResponse, recorder.Body.Bytes())
+			},
+		},
+		{
+			name: "Reused For Another Request",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{
+					Username:     user1.Username,
+					Key:          key,
+					Fingerprint:  "another request",
+					StatusCode:   sql.NullInt32{Int32: http.StatusOK, Valid: true},
+					ResponseBody: storedResponse,
+				}, nil)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "Still Processing",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{
+					Username:    user1.Username,
+					Key:         key,
+					Fingerprint: fingerprint,
+				}, nil)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "Server Error Releases Key",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransactionTxResult{}, sql.ErrConnDone)
+
+				arg := db.DeleteIdempotencyKeyParams{Username: user1.Username, Key: key}
+				store.EXPECT().DeleteIdempotencyKey(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+				store.EXPECT().SaveIdempotencyResponse(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "Panic Releases Key",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, _ db.TransactionTxParams) (db.TransactionTxResult, error) {
+						panic("transfer failed")
+					})
+
+				arg := db.DeleteIdempotencyKeyParams{Username: user1.Username, Key: key}
+				store.EXPECT().DeleteIdempotencyKey(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+				store.EXPECT().SaveIdempotencyResponse(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "Key Too Long",
+			key:  strings.Repeat("k", maxIdempotencyKeyLength+1),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Claim Error",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrConnDone)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/transactions", bytes.NewReader(data))
+			require.NoError(t, err)
+			if currTest.key != "" {
+				request.Header.Set(idempotencyKeyHeader, currTest.key)
+			}
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, user1.Username, util.CustomerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareBodyTooLarge(t *testing.T) {
+	user, _ := randomUser(t)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := mockdb.NewMockStore(controller)
+	store.EXPECT().ClaimIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(0)
+	allowAllTokens(store)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	body := bytes.Repeat([]byte(" "), maxIdempotentBodyBytes+1)
+	request, err := http.NewRequest(http.MethodPost, "/transactions", bytes.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set(idempotencyKeyHeader, "7f4c2a52-5a9f-4bde-9f34-0c6e7a3c9d10")
+
+	addAuth(t, request, server.tokenMaker, authTypeBearer, user.Username, util.CustomerRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
+
+func TestRequestFingerprintMultipart(t *testing.T) {
+	upload := func(boundary string, csv string) (string, []byte) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		require.NoError(t, writer.SetBoundary(boundary))
+		require.NoError(t, writer.WriteField("mode", "best_effort"))
+
+		file, err := writer.CreateFormFile("file", "transfers.csv")
+		require.NoError(t, err)
+		_, err = file.Write([]byte(csv))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		return writer.FormDataContentType(), body.Bytes()
+	}
+
+	csv := "to_account_id,amount\n2,100\n"
+	contentType1, body1 := upload("first-boundary", csv)
+	contentType2, body2 := upload("retry-boundary-2", csv)
+	require.NotEqual(t, body1, body2)
+
+	// A retry with a new boundary is the same request
+	fingerprint := requestFingerprint(http.MethodPost, "/transactions/batch", contentType1, body1)
+	require.Equal(t, fingerprint, requestFingerprint(http.MethodPost, "/transactions/batch", contentType2, body2))
+
+	// Another file is not
+	contentType3, body3 := upload("first-boundary", "to_account_id,amount\n2,200\n")
+	require.NotEqual(t, fingerprint, requestFingerprint(http.MethodPost, "/transactions/batch", contentType3, body3))
+}
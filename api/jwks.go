@@ -0,0 +1,26 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"simplebank/token"
+)
+
+var errNoPublicKeys = errors.New("Tokens are signed with a symmetric key, there are no public keys")
+
+type jwksResponse struct {
+	Keys []token.JSONWebKey `json:"keys"`
+}
+
+// Publishes the keys that downstream services need to verify our tokens
+func (server *Server) getJWKS(ctx *gin.Context) {
+	maker, ok := server.tokenMaker.(token.PublicKeyMaker)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, errorResponse(errNoPublicKeys))
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, jwksResponse{Keys: maker.PublicKeys()})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	"simplebank/db/util"
+	"simplebank/token"
+	"testing"
+	"time"
+)
+
+func randomEd25519Seed(t *testing.T) string {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(privateKey.Seed())
+}
+
+func TestNewTokenMaker(t *testing.T) {
+	symmetricKey := util.RandomString(32)
+	seed := randomEd25519Seed(t)
+
+	testCases := []struct {
+		name      string
+		config    util.Config
+		checkType func(t *testing.T, maker token.Maker, err error)
+	}{
+		{
+			name:   "Default",
+			config: util.Config{TokenSymmetricKey: symmetricKey},
+			checkType: func(t *testing.T, maker token.Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &token.PasetoMaker{}, maker)
+			},
+		},
+		{
+			name:   "JWT",
+			config: util.Config{TokenType: tokenTypeJWT, TokenSymmetricKey: symmetricKey},
+			checkType: func(t *testing.T, maker token.Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &token.JWTMaker{}, maker)
+			},
+		},
+		{
+			name:   "PasetoPublic",
+			config: util.Config{TokenType: tokenTypePasetoPublic, TokenPrivateKey: seed},
+			checkType: func(t *testing.T, maker token.Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &token.PasetoPublicMaker{}, maker)
+			},
+		},
+		{
+			name:   "JWTEdDSA",
+			config: util.Config{TokenType: tokenTypeJWTEdDSA, TokenPrivateKey: seed},
+			checkType: func(t *testing.T, maker token.Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &token.JWTEdDSAMaker{}, maker)
+			},
+		},
+		{
+			name:   "MissingPrivateKey",
+			config: util.Config{TokenType: tokenTypeJWTEdDSA},
+			checkType: func(t *testing.T, maker token.Maker, err error) {
+				require.Error(t, err)
+			},
+		},
+		{
+			name:   "UnknownType",
+			config: util.Config{TokenType: "macaroon", TokenSymmetricKey: symmetricKey},
+			checkType: func(t *testing.T, maker token.Maker, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			maker, err := newTokenMaker(testCase.config)
+			testCase.checkType(t, maker, err)
+		})
+	}
+}
+
+func TestGetJWKSAPI(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := mockdb.NewMockStore(controller)
+	allowAllTokens(store)
+
+	config := util.Config{
+		TokenType:           tokenTypeJWTEdDSA,
+		TokenKeyID:          "2022-01",
+		TokenPrivateKey:     randomEd25519Seed(t),
+		AccessTokenDuration: time.Minute,
+	}
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp jwksResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.Len(t, rsp.Keys, 1)
+	require.Equal(t, "2022-01", rsp.Keys[0].KeyID)
+
+	// A token issued by the server verifies with nothing but the published key
+	accessToken, _, err := server.tokenMaker.CreateToken("testusername", util.CustomerRole, token.AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	rawPublicKey, err := base64.RawURLEncoding.DecodeString(rsp.Keys[0].X)
+	require.NoError(t, err)
+
+	jwtToken, err := jwt.ParseWithClaims(accessToken, &token.Payload{}, func(jwtToken *jwt.Token) (interface{}, error) {
+		require.Equal(t, "EdDSA", jwtToken.Method.Alg())
+		require.Equal(t, rsp.Keys[0].KeyID, jwtToken.Header["kid"])
+		return ed25519.PublicKey(rawPublicKey), nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "testusername", jwtToken.Claims.(*token.Payload).Username)
+}
+
+func TestGetJWKSSymmetricAPI(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(controller))
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
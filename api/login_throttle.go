@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"time"
+)
+
+// Kinds of subject that failed logins are tracked against
+const (
+	loginSubjectUsername = "username"
+	loginSubjectIP       = "ip"
+)
+
+var errLoginLocked = errors.New("Too many failed login attempts, try again later")
+
+// loginThrottle slows down password guessing against a username or from a
+// client IP. Every failure within the window pushes locked_until out with an
+// exponential backoff, and reaching the failure limit locks the subject for
+// the full lockout duration. State lives in Postgres so it survives restarts
+// and is shared between server instances.
+type loginThrottle struct {
+	store           db.Store
+	maxFailures     int32
+	maxIPFailures   int32
+	window          time.Duration
+	lockoutDuration time.Duration
+	backoffBase     time.Duration
+}
+
+func newLoginThrottle(store db.Store, config util.Config) *loginThrottle {
+	return &loginThrottle{
+		store:           store,
+		maxFailures:     config.LoginMaxFailures,
+		maxIPFailures:   config.LoginMaxIPFailures,
+		window:          config.LoginFailureWindow,
+		lockoutDuration: config.LoginLockoutDuration,
+		backoffBase:     config.LoginBackoffBase,
+	}
+}
+
+// Returns the time until which logins for the username or from the IP are refused
+func (throttle *loginThrottle) lockedUntil(ctx context.Context, username string, clientIP string) (time.Time, error) {
+	return throttle.store.GetLoginLockedUntil(ctx, db.GetLoginLockedUntilParams{
+		Username: username,
+		ClientIp: clientIP,
+	})
+}
+
+// Records a failed login against both the username and the client IP
+func (throttle *loginThrottle) recordFailure(ctx context.Context, username string, clientIP string) error {
+	err := throttle.recordSubjectFailure(ctx, loginSubjectUsername, username, throttle.maxFailures)
+	if err != nil {
+		return err
+	}
+
+	return throttle.recordSubjectFailure(ctx, loginSubjectIP, clientIP, throttle.maxIPFailures)
+}
+
+// Clears the failures of the username after a successful login.
+// The client IP keeps its count so that one valid account cannot be used to
+// reset the limit while guessing passwords of other accounts.
+func (throttle *loginThrottle) recordSuccess(ctx context.Context, username string) error {
+	return throttle.unlock(ctx, loginSubjectUsername, username)
+}
+
+func (throttle *loginThrottle) unlock(ctx context.Context, kind string, subject string) error {
+	return throttle.store.ClearLoginFailures(ctx, db.ClearLoginFailuresParams{
+		Kind:    kind,
+		Subject: subject,
+	})
+}
+
+func (throttle *loginThrottle) recordSubjectFailure(ctx context.Context, kind string, subject string, maxFailures int32) error {
+	failure, err := throttle.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Kind:        kind,
+		Subject:     subject,
+		WindowStart: time.Now().Add(-throttle.window),
+	})
+	if err != nil {
+		return err
+	}
+
+	delay := throttle.delay(failure.FailedCount, maxFailures)
+	if delay <= 0 {
+		return nil
+	}
+
+	return throttle.store.LockLoginSubject(ctx, db.LockLoginSubjectParams{
+		Kind:        kind,
+		Subject:     subject,
+		LockedUntil: failure.LastFailedAt.Add(delay),
+	})
+}
+
+// Returns how long the subject must wait after its failedCount-th failure.
+// The delay doubles with every failure and is capped by the lockout duration,
+// which applies in full once maxFailures is reached.
+func (throttle *loginThrottle) delay(failedCount int32, maxFailures int32) time.Duration {
+	if maxFailures > 0 && failedCount >= maxFailures {
+		return throttle.lockoutDuration
+	}
+
+	if throttle.backoffBase <= 0 || failedCount <= 0 {
+		return 0
+	}
+
+	delay := throttle.backoffBase
+	for i := int32(1); i < failedCount && delay < throttle.lockoutDuration; i++ {
+		delay *= 2
+	}
+
+	if delay > throttle.lockoutDuration {
+		return throttle.lockoutDuration
+	}
+	return delay
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/token"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// Stubs out the login throttle for tests that are not about lockouts
+func allowAllLogins(store *mockdb.MockStore) {
+	store.EXPECT().GetLoginLockedUntil(gomock.Any(), gomock.Any()).AnyTimes().Return(time.Time{}, nil)
+	store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).AnyTimes().Return(db.LoginFailure{FailedCount: 1}, nil)
+	store.EXPECT().LockLoginSubject(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+}
+
+func newTestLoginThrottle(store db.Store) *loginThrottle {
+	return newLoginThrottle(store, util.Config{
+		LoginMaxFailures:     5,
+		LoginMaxIPFailures:   20,
+		LoginFailureWindow:   15 * time.Minute,
+		LoginLockoutDuration: 15 * time.Minute,
+		LoginBackoffBase:     time.Second,
+	})
+}
+
+func TestLoginThrottleDelay(t *testing.T) {
+	throttle := newTestLoginThrottle(nil)
+
+	require.Zero(t, throttle.delay(0, 5))
+	require.Equal(t, time.Second, throttle.delay(1, 5))
+	require.Equal(t, 2*time.Second, throttle.delay(2, 5))
+	require.Equal(t, 8*time.Second, throttle.delay(4, 5))
+
+	// Reaching the limit locks the subject for the full lockout duration
+	require.Equal(t, 15*time.Minute, throttle.delay(5, 5))
+	require.Equal(t, 15*time.Minute, throttle.delay(6, 5))
+
+	// The backoff never exceeds the lockout duration
+	require.Equal(t, 15*time.Minute, throttle.delay(19, 20))
+}
+
+func TestLoginThrottleRecordFailure(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := mockdb.NewMockStore(controller)
+	throttle := newTestLoginThrottle(store)
+
+	lastFailedAt := time.Now()
+
+	store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordLoginFailureParams) (db.LoginFailure, error) {
+			require.Equal(t, loginSubjectUsername, arg.Kind)
+			require.Equal(t, "testusername", arg.Subject)
+			require.WithinDuration(t, time.Now().Add(-15*time.Minute), arg.WindowStart, time.Second)
+			return db.LoginFailure{Kind: arg.Kind, Subject: arg.Subject, FailedCount: 5, LastFailedAt: lastFailedAt}, nil
+		})
+	store.EXPECT().LockLoginSubject(gomock.Any(), gomock.Eq(db.LockLoginSubjectParams{
+		Kind:        loginSubjectUsername,
+		Subject:     "testusername",
+		LockedUntil: lastFailedAt.Add(15 * time.Minute),
+	})).Times(1).Return(nil)
+
+	store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordLoginFailureParams) (db.LoginFailure, error) {
+			require.Equal(t, loginSubjectIP, arg.Kind)
+			require.Equal(t, "127.0.0.1", arg.Subject)
+			return db.LoginFailure{Kind: arg.Kind, Subject: arg.Subject, FailedCount: 2, LastFailedAt: lastFailedAt}, nil
+		})
+	store.EXPECT().LockLoginSubject(gomock.Any(), gomock.Eq(db.LockLoginSubjectParams{
+		Kind:        loginSubjectIP,
+		Subject:     "127.0.0.1",
+		LockedUntil: lastFailedAt.Add(2 * time.Second),
+	})).Times(1).Return(nil)
+
+	err := throttle.recordFailure(context.Background(), "testusername", "127.0.0.1")
+	require.NoError(t, err)
+}
+
+func TestLoginIgnoresSpoofedForwardedFor(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := mockdb.NewMockStore(controller)
+	store.EXPECT().GetLoginLockedUntil(gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(_ context.Context, arg db.GetLoginLockedUntilParams) (time.Time, error) {
+			require.Equal(t, "192.0.2.1", arg.ClientIp)
+			return time.Time{}, nil
+		})
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(3).Return(db.User{}, sql.ErrNoRows)
+
+	// Every failure counts against the peer that sent it, whatever header it made up
+	var ipFailures int32
+	store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(6).
+		DoAndReturn(func(_ context.Context, arg db.RecordLoginFailureParams) (db.LoginFailure, error) {
+			if arg.Kind == loginSubjectIP {
+				require.Equal(t, "192.0.2.1", arg.Subject)
+				ipFailures++
+				return db.LoginFailure{FailedCount: ipFailures}, nil
+			}
+			return db.LoginFailure{FailedCount: 1}, nil
+		})
+	store.EXPECT().LockLoginSubject(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	server := newTestServer(t, store)
+
+	for i := 0; i < 3; i++ {
+		res, err := json.Marshal(gin.H{"username": util.RandomString(6), "password": "secret"})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(res))
+		require.NoError(t, err)
+		request.RemoteAddr = "192.0.2.1:40000"
+		request.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	}
+	require.Equal(t, int32(3), ipFailures)
+}
+
+func TestUnlockLoginAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		url           string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "UnlockUsername",
+			url:  "/login_lockouts/username/testusername",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ClearLoginFailuresParams{
+					Kind:    loginSubjectUsername,
+					Subject: "testusername",
+				}
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnlockIP",
+			url:  "/login_lockouts/ip/10.0.0.1",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ClearLoginFailuresParams{
+					Kind:    loginSubjectIP,
+					Subject: "10.0.0.1",
+				}
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidKind",
+			url:  "/login_lockouts/email/testusername",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			url:  "/login_lockouts/username/testusername",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, "testusername", util.CustomerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			url:  "/login_lockouts/username/testusername",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, "admin", util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(1).Return(fmt.Errorf("connection lost"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			testCase.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, testCase.url, nil)
+			require.NoError(t, err)
+
+			testCase.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(recorder)
+		})
+	}
+}
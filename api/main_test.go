@@ -3,22 +3,31 @@ package api
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
+	"io"
 	"os"
 	db "simplebank/db/sqlc"
 	"simplebank/db/util"
+	"simplebank/mail"
 	"testing"
 	"time"
 )
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymmetricKey:     util.RandomString(32),
+		AccessTokenDuration:   time.Minute,
+		RefreshTokenDuration:  time.Hour,
+		MFAChallengeDuration:  time.Minute,
+		PasswordResetURL:      "http://localhost/reset_password",
+		PasswordResetDuration: time.Minute,
 	}
 
 	server, err := NewServer(config, store)
 	require.NoError(t, err)
 
+	// Keep emails out of the test output, tests that read them swap in their own mailer
+	server.mailer = mail.NewLogMailer(io.Discard, "test@simplebank.local")
+
 	return server
 }
 
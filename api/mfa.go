@@ -0,0 +1,286 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/token"
+	"time"
+)
+
+const totpIssuer = "Simple Bank"
+const recoveryCodeCount = 10
+const maxMfaChallengeAttempts = 5
+
+var (
+	errTOTPAlreadyEnabled = errors.New("Two-factor authentication is already enabled")
+	errTOTPNotEnrolled    = errors.New("Two-factor authentication enrollment has not been started")
+	errInvalidMfaCode     = errors.New("Invalid two-factor authentication code")
+	errMfaChallengeUsed   = errors.New("MFA challenge has already been used")
+	errMfaChallengeExpiry = errors.New("MFA challenge has expired")
+	errMfaTooManyAttempts = errors.New("Too many attempts for this MFA challenge")
+)
+
+type enrollTOTPResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type confirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type mfaChallengeResponse struct {
+	MfaRequired bool      `json:"mfa_required"`
+	MfaToken    uuid.UUID `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type loginUserMfaRequest struct {
+	MfaToken     uuid.UUID `json:"mfa_token" binding:"required"`
+	Code         string    `json:"code" binding:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string    `json:"recovery_code" binding:"required_without=Code"`
+}
+
+// Starts TOTP enrollment by generating a new secret that has yet to be confirmed
+func (server *Server) enrollTOTP(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.TotpEnabled {
+		ctx.JSON(http.StatusConflict, errorResponse(errTOTPAlreadyEnabled))
+		return
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.SetUserTOTPSecret(ctx, db.SetUserTOTPSecretParams{
+		Username:   user.Username,
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := enrollTOTPResponse{
+		Secret:          secret,
+		ProvisioningURI: util.TOTPProvisioningURI(totpIssuer, user.Username, secret),
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// Finishes TOTP enrollment once the user proves their authenticator produces valid codes
+func (server *Server) confirmTOTP(ctx *gin.Context) {
+	var req confirmTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.TotpEnabled {
+		ctx.JSON(http.StatusConflict, errorResponse(errTOTPAlreadyEnabled))
+		return
+	}
+
+	if !user.TotpSecret.Valid {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTOTPNotEnrolled))
+		return
+	}
+
+	step, ok := util.ValidTOTPCode(user.TotpSecret.String, req.Code, time.Now())
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMfaCode))
+		return
+	}
+
+	recoveryCodes, err := util.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	hashedCodes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		hashedCodes[i] = util.HashToken(code)
+	}
+
+	_, err = server.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		Username:            user.Username,
+		HashedRecoveryCodes: hashedCodes,
+		TotpStep:            step,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmTOTPResponse{RecoveryCodes: recoveryCodes})
+}
+
+// First login step for users with TOTP enabled: the password was correct, now ask for a code
+func (server *Server) startMfaChallenge(ctx *gin.Context, user db.User) {
+	challenge, err := server.store.CreateMfaChallenge(ctx, db.CreateMfaChallengeParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(server.config.MFAChallengeDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := mfaChallengeResponse{
+		MfaRequired: true,
+		MfaToken:    challenge.ID,
+		ExpiresAt:   challenge.ExpiresAt,
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// Second login step: exchanges the MFA challenge and a TOTP or recovery code for real tokens
+func (server *Server) loginUserMfa(ctx *gin.Context) {
+	var req loginUserMfaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	challenge, err := server.store.GetMfaChallenge(ctx, req.MfaToken)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if challenge.UsedAt.Valid {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errMfaChallengeUsed))
+		return
+	}
+
+	if time.Now().After(challenge.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errMfaChallengeExpiry))
+		return
+	}
+
+	if challenge.Attempts >= maxMfaChallengeAttempts {
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errMfaTooManyAttempts))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, challenge.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	step, valid, err := server.validMfaCode(ctx, user, req.Code, req.RecoveryCode)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !valid {
+		_, err = server.store.IncrementMfaChallengeAttempts(ctx, challenge.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		server.recordLoginFailure(ctx, user.Username, ctx.ClientIP(), http.StatusUnauthorized, errInvalidMfaCode)
+		return
+	}
+
+	// Only one concurrent request may consume the challenge, or the TOTP code it was answered with
+	rows, err := server.store.UseMfaChallenge(ctx, db.UseMfaChallengeParams{
+		ID:       challenge.ID,
+		TotpStep: step,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rows == 0 {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errMfaChallengeUsed))
+		return
+	}
+
+	err = server.loginThrottle.recordSuccess(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.createUserSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// Checks a TOTP code, or else consumes a recovery code.
+// Returns the time step of an accepted TOTP code, or 0 when a recovery code was used.
+func (server *Server) validMfaCode(ctx *gin.Context, user db.User, code string, recoveryCode string) (int64, bool, error) {
+	if code != "" {
+		if !user.TotpSecret.Valid {
+			return 0, false, nil
+		}
+		// RFC 6238 §5.2: a code is only good once, nor is any code older than the last accepted one
+		step, ok := util.ValidTOTPCode(user.TotpSecret.String, code, time.Now())
+		return step, ok && step > user.TotpLastStep, nil
+	}
+
+	storedCode, err := server.store.GetRecoveryCode(ctx, db.GetRecoveryCodeParams{
+		Username:   user.Username,
+		HashedCode: util.HashToken(recoveryCode),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	rows, err := server.store.UseRecoveryCode(ctx, storedCode.ID)
+	if err != nil {
+		return 0, false, err
+	}
+	return 0, rows == 1, nil
+}
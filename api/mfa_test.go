@@ -0,0 +1,475 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"testing"
+	"time"
+)
+
+func randomTOTPUser(t *testing.T, enabled bool) (db.User, string) {
+	user, password := randomUser(t)
+
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	user.TotpSecret = sql.NullString{String: secret, Valid: true}
+	user.TotpEnabled = enabled
+	return user, password
+}
+
+func currentTOTPCode(t *testing.T, user db.User) string {
+	code, err := util.GenerateTOTPCode(user.TotpSecret.String, time.Now())
+	require.NoError(t, err)
+	return code
+}
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	enabledUser, _ := randomTOTPUser(t, true)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().SetUserTOTPSecret(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp enrollTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.Secret)
+				require.Contains(t, rsp.ProvisioningURI, "otpauth://totp/")
+				require.Contains(t, rsp.ProvisioningURI, rsp.Secret)
+			},
+		},
+		{
+			name:     "AlreadyEnabled",
+			username: enabledUser.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(enabledUser.Username)).Times(1).Return(enabledUser, nil)
+				store.EXPECT().SetUserTOTPSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().SetUserTOTPSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			testCase.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp", nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, testCase.username, util.CustomerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(recorder)
+		})
+	}
+}
+
+func TestConfirmTOTPAPI(t *testing.T) {
+	pendingUser, _ := randomTOTPUser(t, false)
+	notEnrolledUser, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		code          func() string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: pendingUser,
+			code: func() string {
+				return currentTOTPCode(t, pendingUser)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(pendingUser.Username)).Times(1).Return(pendingUser, nil)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.EnableTOTPTxParams) (db.User, error) {
+						require.Equal(t, pendingUser.Username, arg.Username)
+						require.Len(t, arg.HashedRecoveryCodes, recoveryCodeCount)
+						require.InDelta(t, time.Now().Unix()/30, arg.TotpStep, 1)
+						return pendingUser, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp confirmTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.RecoveryCodes, recoveryCodeCount)
+			},
+		},
+		{
+			name: "WrongCode",
+			user: pendingUser,
+			code: func() string {
+				code, err := util.GenerateTOTPCode(pendingUser.TotpSecret.String, time.Now().Add(-time.Hour))
+				require.NoError(t, err)
+				return code
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(pendingUser.Username)).Times(1).Return(pendingUser, nil)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			user: notEnrolledUser,
+			code: func() string {
+				return "123456"
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(notEnrolledUser.Username)).Times(1).Return(notEnrolledUser, nil)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCodeFormat",
+			user: pendingUser,
+			code: func() string {
+				return "abc"
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			testCase.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			res, err := json.Marshal(gin.H{"code": testCase.code()})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp/confirm", bytes.NewReader(res))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, testCase.user.Username, util.CustomerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(recorder)
+		})
+	}
+}
+
+func TestLoginUserWithTOTPAPI(t *testing.T) {
+	user, password := randomTOTPUser(t, true)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := mockdb.NewMockStore(controller)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().CreateMfaChallenge(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ interface{}, arg db.CreateMfaChallengeParams) (db.MfaChallenge, error) {
+			require.Equal(t, user.Username, arg.Username)
+			return db.MfaChallenge{ID: arg.ID, Username: arg.Username, ExpiresAt: arg.ExpiresAt}, nil
+		})
+	store.EXPECT().GetLoginLockedUntil(gomock.Any(), gomock.Any()).Times(1).Return(time.Time{}, nil)
+	// The password alone does not clear earlier failures
+	store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	res, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(res))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp mfaChallengeResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.True(t, rsp.MfaRequired)
+	require.NotZero(t, rsp.MfaToken)
+}
+
+func TestLoginUserMfaAPI(t *testing.T) {
+	user, _ := randomTOTPUser(t, true)
+	recoveryCode := "abcde-fghij"
+
+	// The user already logged in with the code of this step
+	usedAt := time.Now()
+	replayedUser := user
+	replayedUser.TotpLastStep = usedAt.Unix() / 30
+
+	newChallenge := func() db.MfaChallenge {
+		return db.MfaChallenge{
+			ID:        uuid.New(),
+			Username:  user.Username,
+			ExpiresAt: time.Now().Add(time.Minute),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		challenge     db.MfaChallenge
+		body          func(challenge db.MfaChallenge) gin.H
+		buildStubs    func(store *mockdb.MockStore, challenge db.MfaChallenge)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			challenge: newChallenge(),
+			body: func(challenge db.MfaChallenge) gin.H {
+				return gin.H{"mfa_token": challenge.ID, "code": currentTOTPCode(t, user)}
+			},
+			buildStubs: func(store *mockdb.MockStore, challenge db.MfaChallenge) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UseMfaChallengeParams) (int64, error) {
+						require.Equal(t, challenge.ID, arg.ID)
+						require.InDelta(t, time.Now().Unix()/30, arg.TotpStep, 1)
+						return 1, nil
+					})
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Eq(db.ClearLoginFailuresParams{
+					Kind:    loginSubjectUsername,
+					Subject: user.Username,
+				})).Times(1).Return(nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+			},
+		},
+		{
+			name:      "RecoveryCode",
+			challenge: newChallenge(),
+			body: func(challenge db.MfaChallenge) gin.H {
+				return gin.H{"mfa_token": challenge.ID, "recovery_code": recoveryCode}
+			},
+			buildStubs: func(store *mockdb.MockStore, challenge db.MfaChallenge) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+
+				arg := db.GetRecoveryCodeParams{
+					Username:   user.Username,
+					HashedCode: util.HashToken(recoveryCode),
+				}
+				store.EXPECT().GetRecoveryCode(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.RecoveryCode{ID: 1}, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(int64(1), nil)
+				// A recovery code has no step to record
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Eq(db.UseMfaChallengeParams{ID: challenge.ID})).Times(1).Return(int64(1), nil)
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Eq(db.ClearLoginFailuresParams{
+					Kind:    loginSubjectUsername,
+					Subject: user.Username,
+				})).Times(1).Return(nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "WrongCode",
+			challenge: newChallenge(),
+			body: func(challenge db.MfaChallenge) gin.H {
+				return gin.H{"mfa_token": challenge.ID, "code": "000000"}
+			},
+			buildStubs: func(store *mockdb.MockStore, challenge db.MfaChallenge) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().IncrementMfaChallengeAttempts(gomock.Any(), gomock.Eq(challenge.ID)).Times(1)
+				// Counted against both the username and the client IP
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginFailure{FailedCount: 1}, nil)
+				store.EXPECT().LockLoginSubject(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "ReplayedCode",
+			challenge: newChallenge(),
+			body: func(challenge db.MfaChallenge) gin.H {
+				code, err := util.GenerateTOTPCode(user.TotpSecret.String, usedAt)
+				require.NoError(t, err)
+				return gin.H{"mfa_token": challenge.ID, "code": code}
+			},
+			buildStubs: func(store *mockdb.MockStore, challenge db.MfaChallenge) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(replayedUser, nil)
+				store.EXPECT().IncrementMfaChallengeAttempts(gomock.Any(), gomock.Eq(challenge.ID)).Times(1)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginFailure{FailedCount: 1}, nil)
+				store.EXPECT().LockLoginSubject(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredChallenge",
+			challenge: func() db.MfaChallenge {
+				challenge := newChallenge()
+				challenge.ExpiresAt = time.Now().Add(-time.Minute)
+				return challenge
+			}(),
+			body: func(challenge db.MfaChallenge) gin.H {
+				return gin.H{"mfa_token": challenge.ID, "code": currentTOTPCode(t, user)}
+			},
+			buildStubs: func(store *mockdb.MockStore, challenge db.MfaChallenge) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UsedChallenge",
+			challenge: func() db.MfaChallenge {
+				challenge := newChallenge()
+				challenge.UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				return challenge
+			}(),
+			body: func(challenge db.MfaChallenge) gin.H {
+				return gin.H{"mfa_token": challenge.ID, "code": currentTOTPCode(t, user)}
+			},
+			buildStubs: func(store *mockdb.MockStore, challenge db.MfaChallenge) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyAttempts",
+			challenge: func() db.MfaChallenge {
+				challenge := newChallenge()
+				challenge.Attempts = maxMfaChallengeAttempts
+				return challenge
+			}(),
+			body: func(challenge db.MfaChallenge) gin.H {
+				return gin.H{"mfa_token": challenge.ID, "code": currentTOTPCode(t, user)}
+			},
+			buildStubs: func(store *mockdb.MockStore, challenge db.MfaChallenge) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingCode",
+			challenge: newChallenge(),
+			body: func(challenge db.MfaChallenge) gin.H {
+				return gin.H{"mfa_token": challenge.ID}
+			},
+			buildStubs: func(store *mockdb.MockStore, challenge db.MfaChallenge) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			testCase.buildStubs(store, testCase.challenge)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			res, err := json.Marshal(testCase.body(testCase.challenge))
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewReader(res))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(recorder)
+		})
+	}
+}
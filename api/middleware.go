@@ -1,27 +1,38 @@
 package api
 
 import (
+	"database/sql"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
 	"simplebank/token"
 	"strings"
 )
 
 const authTypeBearer = "bearer"
+const authTypeAPIKey = "apikey"
 const authHeaderKey = "authorization"
 const authPayLoadKey = "authorization_payload"
+const authScopesKey = "authorization_scopes"
 
 const noAuthHeader = "Authorization header is not provided."
 const invalidAuthHeader = "Invalid authorization header format."
 const unSupportedAuth = "Server does not support the current auth method"
+const revokedAuth = "Token has been revoked"
+const notAccessToken = "Token is not an access token"
+const forbiddenRole = "The user role is not allowed to access this resource"
+const unverifiedEmail = "Email must be verified before using this resource"
+const missingScope = "The API key does not have the scope required by this resource"
 
 func errRes(errorMessage string) gin.H {
 	err := errors.New(errorMessage)
 	return errorResponse(err)
 }
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// Authenticates bearer tokens, and API keys as well when apiKeys is not nil
+func authMiddleware(tokenMaker token.Maker, revocations *revocationList, apiKeys *apiKeyAuthenticator) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -32,20 +43,125 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		tokenFields := strings.Fields(authorizationHeader)
 		if len(tokenFields) < 2 {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errRes(invalidAuthHeader))
+			return
 		}
 
 		authType := strings.ToLower(tokenFields[0])
+		if authType == authTypeAPIKey && apiKeys != nil {
+			payload, scopes, err := apiKeys.authenticate(ctx, tokenFields[1])
+			if err != nil {
+				switch err {
+				case errInvalidAPIKey, errAPIKeyRevoked, errAPIKeyExpired:
+					ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				default:
+					ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+				}
+				return
+			}
+
+			ctx.Set(authPayLoadKey, payload)
+			ctx.Set(authScopesKey, scopes)
+			ctx.Next()
+			return
+		}
+
 		if authType != authTypeBearer {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errRes(unSupportedAuth))
+			return
 		}
 
 		accessToken := tokenFields[1]
 		payload, err := tokenMaker.VerifyToken(accessToken)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errRes(unSupportedAuth))
+			return
+		}
+
+		// Refresh tokens live much longer and may only renew access tokens
+		if payload.Type != token.AccessToken {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errRes(notAccessToken))
+			return
+		}
+
+		revoked, err := revocations.isRevoked(ctx, payload)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if revoked {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errRes(revokedAuth))
+			return
 		}
 
 		ctx.Set(authPayLoadKey, payload)
 		ctx.Next()
 	}
 }
+
+// Requires API keys to carry the scope. Bearer tokens act with the full
+// rights of the user and are not limited by scopes.
+func scopeMiddleware(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		scopes, ok := ctx.Get(authScopesKey)
+		if !ok {
+			ctx.Next()
+			return
+		}
+
+		for _, granted := range scopes.([]string) {
+			if granted == scope {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errRes(missingScope))
+	}
+}
+
+func roleMiddleware(allowedRoles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+		for _, role := range allowedRoles {
+			if payload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errRes(forbiddenRole))
+	}
+}
+
+// Rejects users whose email is not verified yet when the policy is enabled
+func verifiedEmailMiddleware(store db.Store, required bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !required {
+			ctx.Next()
+			return
+		}
+
+		payload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+		user, err := store.GetUser(ctx, payload.Username)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if !user.IsEmailVerified {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errRes(unverifiedEmail))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// Tellers and admins may look at any customer's resources
+func isStaff(role string) bool {
+	return role == util.TellerRole || role == util.AdminRole
+}
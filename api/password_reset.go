@@ -0,0 +1,156 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/mail"
+	"time"
+)
+
+// Entropy of a password reset token in bytes
+const passwordResetTokenSize = 32
+
+var (
+	errInvalidResetToken = errors.New("Invalid password reset token")
+	errResetTokenExpired = errors.New("Password reset token has expired")
+)
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// Emails a one-time password reset link. The response is the same whether or
+// not the email is registered so that it cannot be used to discover accounts.
+func (server *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusOK, "Password Reset Email Sent.")
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	resetToken, err := util.GenerateSecureToken(passwordResetTokenSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		Username:    user.Username,
+		HashedToken: util.HashToken(resetToken),
+		ExpiresAt:   time.Now().Add(server.config.PasswordResetDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.mailer.SendEmail(ctx, newPasswordResetEmail(user, server.config, resetToken))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, "Password Reset Email Sent.")
+}
+
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := server.passwordPolicy.Validate(req.NewPassword); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	resetToken, err := server.store.GetPasswordResetToken(ctx, util.HashToken(req.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidResetToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if resetToken.UsedAt.Valid {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(db.ErrPasswordResetTokenUsed))
+		return
+	}
+
+	if time.Now().After(resetToken.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errResetTokenExpired))
+		return
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenID:        resetToken.ID,
+		Username:       resetToken.Username,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if err == db.ErrPasswordResetTokenUsed {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The transaction revoked the tokens of the user
+	server.revocations.forget(user.Username)
+
+	err = server.loginThrottle.unlock(ctx, loginSubjectUsername, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, "Password Reset Successed.")
+}
+
+func newPasswordResetEmail(user db.User, config util.Config, resetToken string) mail.Email {
+	link := config.PasswordResetURL + "?token=" + url.QueryEscape(resetToken)
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nUse the link below to reset your Simple Bank password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask for a password reset you can ignore this email.\n",
+		user.FirstName,
+		config.PasswordResetDuration,
+		link,
+	)
+
+	return mail.Email{
+		To:      []string{user.Email},
+		Subject: "Reset your Simple Bank password",
+		Body:    body,
+	}
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/mail"
+	"testing"
+	"time"
+)
+
+var resetLinkPattern = regexp.MustCompile(`\?token=(\S+)`)
+
+func TestForgotPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, sentEmails string)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(time.Minute), arg.ExpiresAt, time.Second)
+						return db.PasswordResetToken{Username: arg.Username, HashedToken: arg.HashedToken, ExpiresAt: arg.ExpiresAt}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, sentEmails string) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, sentEmails, "To: "+user.Email)
+				require.Regexp(t, resetLinkPattern, sentEmails)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			body: gin.H{"email": "nobody@example.com"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, sentEmails string) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, sentEmails)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{"email": "not-an-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, sentEmails string) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, sentEmails string) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Empty(t, sentEmails)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			testCase.buildStubs(store)
+
+			server := newTestServer(t, store)
+			var sentEmails bytes.Buffer
+			server.mailer = mail.NewLogMailer(&sentEmails, "test@simplebank.local")
+			recorder := httptest.NewRecorder()
+
+			res, err := json.Marshal(testCase.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/password/forgot", bytes.NewReader(res))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(recorder, sentEmails.String())
+		})
+	}
+}
+
+func TestForgotPasswordEmailToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	var hashedToken string
+	store := mockdb.NewMockStore(controller)
+	store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+	store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ interface{}, arg db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+			hashedToken = arg.HashedToken
+			return db.PasswordResetToken{}, nil
+		})
+
+	server := newTestServer(t, store)
+	var sentEmails bytes.Buffer
+	server.mailer = mail.NewLogMailer(&sentEmails, "test@simplebank.local")
+	recorder := httptest.NewRecorder()
+
+	res, err := json.Marshal(gin.H{"email": user.Email})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users/password/forgot", bytes.NewReader(res))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// Only the hash of the emailed token is stored
+	match := resetLinkPattern.FindStringSubmatch(sentEmails.String())
+	require.Len(t, match, 2)
+
+	resetToken, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	require.NotEqual(t, resetToken, hashedToken)
+	require.Equal(t, util.HashToken(resetToken), hashedToken)
+}
+
+func TestResetPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	rawToken := util.RandomString(32)
+	newPassword := util.RandomString(8)
+
+	validToken := db.PasswordResetToken{
+		ID:          util.RandomInt(1, 1000),
+		Username:    user.Username,
+		HashedToken: util.HashToken(rawToken),
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": rawToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPasswordResetToken(gomock.Any(), gomock.Eq(util.HashToken(rawToken))).Times(1).Return(validToken, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ResetPasswordTxParams) (db.User, error) {
+						require.Equal(t, validToken.ID, arg.TokenID)
+						require.Equal(t, user.Username, arg.Username)
+						require.NoError(t, util.ValidPassword(newPassword, arg.HashedPassword))
+						return user, nil
+					})
+				// Revoked within the transaction
+				store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Any()).Times(0)
+
+				arg := db.ClearLoginFailuresParams{
+					Kind:    loginSubjectUsername,
+					Subject: user.Username,
+				}
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownToken",
+			body: gin.H{"token": rawToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPasswordResetToken(gomock.Any(), gomock.Any()).Times(1).Return(db.PasswordResetToken{}, sql.ErrNoRows)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UsedToken",
+			body: gin.H{"token": rawToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				usedToken := validToken
+				usedToken.UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().GetPasswordResetToken(gomock.Any(), gomock.Any()).Times(1).Return(usedToken, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			body: gin.H{"token": rawToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredToken := validToken
+				expiredToken.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().GetPasswordResetToken(gomock.Any(), gomock.Any()).Times(1).Return(expiredToken, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "TokenUsedConcurrently",
+			body: gin.H{"token": rawToken, "new_password": newPassword},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPasswordResetToken(gomock.Any(), gomock.Any()).Times(1).Return(validToken, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrPasswordResetTokenUsed)
+				store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ShortPassword",
+			body: gin.H{"token": rawToken, "new_password": "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			testCase.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			res, err := json.Marshal(testCase.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/password/reset", bytes.NewReader(res))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(recorder)
+		})
+	}
+}
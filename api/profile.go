@@ -0,0 +1,177 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"log"
+	"math"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/token"
+	"time"
+)
+
+var (
+	errNoProfileChanges = errors.New("At least one profile field must be provided")
+	errSamePassword     = errors.New("New password must be different from the current password")
+)
+
+// Fields left out of the request keep their current value
+type updateProfileRequest struct {
+	FirstName     *string `json:"first_name" binding:"omitempty,min=1,max=100"`
+	LastName      *string `json:"last_name" binding:"omitempty,min=1,max=100"`
+	Email         *string `json:"email" binding:"omitempty,email"`
+	ContactNumber *string `json:"contact_number" binding:"omitempty,e164"`
+	Address       *string `json:"address" binding:"omitempty,address"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+func (server *Server) getProfile(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (server *Server) updateProfile(ctx *gin.Context) {
+	var req updateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.FirstName == nil && req.LastName == nil && req.Email == nil &&
+		req.ContactNumber == nil && req.Address == nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errNoProfileChanges))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	user, err := server.store.UpdateUser(ctx, db.UpdateUserParams{
+		Username:      authPayload.Username,
+		FirstName:     nullString(req.FirstName),
+		LastName:      nullString(req.LastName),
+		Email:         nullString(req.Email),
+		ContactNumber: nullString(req.ContactNumber),
+		Address:       nullString(req.Address),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// A new email address stays unverified until its owner follows the link
+	if req.Email != nil && !user.IsEmailVerified {
+		err = server.sendVerifyEmail(ctx, user)
+		if err != nil {
+			log.Printf("cannot send verification email to %s: %v", user.Username, err)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// Changes the password of the current user and logs them out everywhere,
+// including the session that made the change
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.NewPassword == req.CurrentPassword {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errSamePassword))
+		return
+	}
+
+	if err := server.passwordPolicy.Validate(req.NewPassword); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	clientIP := ctx.ClientIP()
+
+	// A stolen access token must not become a way around the login lockout
+	lockedUntil, err := server.loginThrottle.lockedUntil(ctx, authPayload.Username, clientIP)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if retryAfter := time.Until(lockedUntil); retryAfter > 0 {
+		ctx.Header("Retry-After", fmt.Sprint(int64(math.Ceil(retryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errLoginLocked))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = util.ValidPassword(req.CurrentPassword, user.HashedPassword)
+	if err != nil {
+		server.recordLoginFailure(ctx, user.Username, clientIP, http.StatusUnauthorized, err)
+		return
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.ChangePasswordTx(ctx, db.ChangePasswordTxParams{
+		Username:       user.Username,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The transaction revoked the tokens of the user
+	server.revocations.forget(user.Username)
+
+	ctx.JSON(http.StatusOK, "Password Change Successed.")
+}
+
+func nullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
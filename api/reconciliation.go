@@ -0,0 +1,99 @@
+package api
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	db "simplebank/db/sqlc"
+)
+
+type createReconciliationRequest struct {
+	FreezeAccounts bool `json:"freeze_accounts"`
+}
+
+type reconciliationByIdRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listReconciliationRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+type reconciliationResponse struct {
+	Run             db.ReconciliationRun     `json:"run"`
+	Drifts          []db.ReconciliationDrift `json:"drifts"`
+	DriftByCurrency map[string]int64         `json:"drift_by_currency"`
+}
+
+func newReconciliationResponse(run db.ReconciliationRun, drifts []db.ReconciliationDrift) reconciliationResponse {
+	return reconciliationResponse{
+		Run:             run,
+		Drifts:          drifts,
+		DriftByCurrency: db.SumDriftByCurrency(drifts),
+	}
+}
+
+// Runs a reconciliation on demand, the same as the scheduled job
+func (server *Server) createReconciliation(ctx *gin.Context) {
+	var req createReconciliationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ReconcileTx(ctx, db.ReconcileTxParams{
+		FreezeAccounts: req.FreezeAccounts,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newReconciliationResponse(result.Run, result.Drifts))
+}
+
+func (server *Server) getReconciliation(ctx *gin.Context) {
+	var req reconciliationByIdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	run, err := server.store.GetReconciliationRun(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	drifts, err := server.store.ListReconciliationDrifts(ctx, run.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newReconciliationResponse(run, drifts))
+}
+
+func (server *Server) listReconciliations(ctx *gin.Context) {
+	var req listReconciliationRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	runs, err := server.store.ListReconciliationRuns(ctx, db.ListReconciliationRunsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, runs)
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"testing"
+	"time"
+)
+
+func randomReconciliation() (db.ReconciliationRun, []db.ReconciliationDrift) {
+	run := db.ReconciliationRun{
+		ID:              util.RandomInt(1, 1000),
+		DriftedAccounts: 3,
+		CreatedAt:       time.Now().UTC().Truncate(time.Second),
+	}
+
+	drifts := []db.ReconciliationDrift{
+		{ID: 1, RunID: run.ID, AccountID: 1, Currency: util.USD, Balance: 100, RecordsBalance: 90, Drift: 10},
+		{ID: 2, RunID: run.ID, AccountID: 2, Currency: util.USD, Balance: 50, RecordsBalance: 55, Drift: -5},
+		{ID: 3, RunID: run.ID, AccountID: 3, Currency: util.EUR, Balance: 20, RecordsBalance: 0, Drift: 20},
+	}
+	return run, drifts
+}
+
+func requireBodyMatchReconciliation(t *testing.T, body *bytes.Buffer, run db.ReconciliationRun, drifts []db.ReconciliationDrift) {
+	data, err := ioutil.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotResponse reconciliationResponse
+	err = json.Unmarshal(data, &gotResponse)
+	require.NoError(t, err)
+
+	require.Equal(t, run, gotResponse.Run)
+	require.Equal(t, drifts, gotResponse.Drifts)
+	require.Equal(t, map[string]int64{util.USD: 5, util.EUR: 20}, gotResponse.DriftByCurrency)
+}
+
+func TestCreateReconciliationAPI(t *testing.T) {
+	run, drifts := randomReconciliation()
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ReconcileTxParams{FreezeAccounts: false}
+				store.EXPECT().ReconcileTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.ReconcileTxResult{Run: run, Drifts: drifts}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchReconciliation(t, recorder.Body, run, drifts)
+			},
+		},
+		{
+			name: "Freeze Accounts",
+			body: gin.H{"freeze_accounts": true},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ReconcileTxParams{FreezeAccounts: true}
+				store.EXPECT().ReconcileTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.ReconcileTxResult{Run: run, Drifts: drifts}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Teller Forbidden",
+			body: gin.H{},
+			role: util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReconcileTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "Internal Error",
+			body: gin.H{},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReconcileTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReconcileTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(currTest.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/reconciliations", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, "admin", currTest.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetReconciliationAPI(t *testing.T) {
+	run, drifts := randomReconciliation()
+
+	testCases := []struct {
+		name          string
+		runID         int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			runID: run.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(run, nil)
+				store.EXPECT().ListReconciliationDrifts(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(drifts, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchReconciliation(t, recorder.Body, run, drifts)
+			},
+		},
+		{
+			name:  "Not Found",
+			runID: run.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(db.ReconciliationRun{}, sql.ErrNoRows)
+				store.EXPECT().ListReconciliationDrifts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "Invalid ID",
+			runID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/reconciliations/%d", currTest.runID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, "admin", util.AdminRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upper bound on cached token lookups before expired entries are pruned
+const maxRevocationCacheEntries = 10000
+
+type revocationEntry struct {
+	username  string
+	revoked   bool
+	checkedAt time.Time
+	expiresAt time.Time
+}
+
+// revocationList answers whether a token was revoked before it expired.
+// Revocations are persisted in Postgres; lookups are cached in memory.
+// A revoked token is cached until it expires, while a token that was not
+// revoked is only trusted for cacheTTL so that revocations made by other
+// server instances are picked up.
+type revocationList struct {
+	store    db.Store
+	cacheTTL time.Duration
+
+	mutex   sync.RWMutex
+	entries map[uuid.UUID]revocationEntry
+}
+
+func newRevocationList(store db.Store, cacheTTL time.Duration) *revocationList {
+	return &revocationList{
+		store:    store,
+		cacheTTL: cacheTTL,
+		entries:  make(map[uuid.UUID]revocationEntry),
+	}
+}
+
+func (list *revocationList) isRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	list.mutex.RLock()
+	entry, ok := list.entries[payload.ID]
+	list.mutex.RUnlock()
+
+	if ok && (entry.revoked || time.Since(entry.checkedAt) < list.cacheTTL) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := list.store.IsTokenRevoked(ctx, db.IsTokenRevokedParams{
+		ID:       payload.ID,
+		Username: payload.Username,
+		IssuedAt: payload.IssuedAt,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	list.remember(payload, revoked)
+	return revoked, nil
+}
+
+// Revokes a single token, e.g. the access token used to log out
+func (list *revocationList) revoke(ctx context.Context, payload *token.Payload) error {
+	err := list.store.CreateRevokedToken(ctx, db.CreateRevokedTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: payload.ExpiredAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	list.remember(payload, true)
+	return nil
+}
+
+// Revokes every token issued to the user so far and blocks all of their sessions
+func (list *revocationList) revokeAll(ctx context.Context, username string) error {
+	err := list.store.RevokeUserTokensTx(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	list.forget(username)
+	return nil
+}
+
+// Drops the cached lookups of the user after their tokens were revoked in the
+// store, so the revocation is seen on the next request
+func (list *revocationList) forget(username string) {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	for id, entry := range list.entries {
+		if entry.username == username {
+			delete(list.entries, id)
+		}
+	}
+}
+
+func (list *revocationList) remember(payload *token.Payload, revoked bool) {
+	now := time.Now()
+
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if len(list.entries) >= maxRevocationCacheEntries {
+		for id, entry := range list.entries {
+			if now.After(entry.expiresAt) {
+				delete(list.entries, id)
+			}
+		}
+		if len(list.entries) >= maxRevocationCacheEntries {
+			list.entries = make(map[uuid.UUID]revocationEntry)
+		}
+	}
+
+	list.entries[payload.ID] = revocationEntry{
+		username:  payload.Username,
+		revoked:   revoked,
+		checkedAt: now,
+		expiresAt: payload.ExpiredAt,
+	}
+}
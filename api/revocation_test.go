@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	mockdb "simplebank/db/mock"
+	"simplebank/db/util"
+	"simplebank/token"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationListCache(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := mockdb.NewMockStore(controller)
+	list := newRevocationList(store, time.Minute)
+
+	payload, err := token.NewPayload("testusername", util.CustomerRole, token.AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	// A token that is not revoked is only looked up once within the cache TTL
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+	for i := 0; i < 3; i++ {
+		revoked, err := list.isRevoked(context.Background(), payload)
+		require.NoError(t, err)
+		require.False(t, revoked)
+	}
+
+	// Revoking the token is visible immediately without another lookup
+	store.EXPECT().CreateRevokedToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	err = list.revoke(context.Background(), payload)
+	require.NoError(t, err)
+
+	revoked, err := list.isRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevocationListRevokeAll(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := mockdb.NewMockStore(controller)
+	list := newRevocationList(store, time.Minute)
+
+	payload, err := token.NewPayload("testusername", util.CustomerRole, token.AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+	revoked, err := list.isRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// Revoking all tokens of the user drops the cached answer
+	store.EXPECT().RevokeUserTokensTx(gomock.Any(), gomock.Eq(payload.Username)).Times(1).Return(nil)
+	err = list.revokeAll(context.Background(), payload.Username)
+	require.NoError(t, err)
+
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+	revoked, err = list.isRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
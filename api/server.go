@@ -4,6 +4,7 @@ import (
 	"fmt"
 	db "simplebank/db/sqlc"
 	"simplebank/db/util"
+	"simplebank/mail"
 	"simplebank/token"
 
 	"github.com/gin-gonic/gin"
@@ -12,55 +13,208 @@ import (
 )
 
 type Server struct {
-	store      db.Store
-	router     *gin.Engine
-	tokenMaker token.Maker
-	config     util.Config
+	store          db.Store
+	router         *gin.Engine
+	tokenMaker     token.Maker
+	revocations    *revocationList
+	apiKeys        *apiKeyAuthenticator
+	loginThrottle  *loginThrottle
+	mailer         mail.Mailer
+	passwordPolicy *util.PasswordPolicy
+	passwordHasher *util.PasswordHasher
+	config         util.Config
 }
 
 func NewServer(config util.Config, store db.Store) (*Server, error) {
 
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	tokenMaker, err := newTokenMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("Not able to create token: %w", err)
 	}
 
+	mailer, err := mail.NewMailer(config)
+	if err != nil {
+		return nil, fmt.Errorf("Not able to create mailer: %w", err)
+	}
+
+	passwordPolicy, err := util.NewPasswordPolicy(config)
+	if err != nil {
+		return nil, fmt.Errorf("Not able to create password policy: %w", err)
+	}
+
+	passwordHasher, err := util.NewPasswordHasher(config)
+	if err != nil {
+		return nil, fmt.Errorf("Not able to create password hasher: %w", err)
+	}
+
+	if _, err := util.ParseSpread(config.FXSpread); err != nil {
+		return nil, err
+	}
+
 	server := &Server{
-		store:      store,
-		tokenMaker: tokenMaker,
-		config:     config,
+		store:          store,
+		tokenMaker:     tokenMaker,
+		revocations:    newRevocationList(store, config.RevocationCacheTTL),
+		apiKeys:        newAPIKeyAuthenticator(store),
+		loginThrottle:  newLoginThrottle(store, config),
+		mailer:         mailer,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
+		config:         config,
 	}
 
 	if valid, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		valid.RegisterValidation("currency", validCurrency)
+		valid.RegisterValidation("role", validRole)
+		valid.RegisterValidation("scope", validScope)
+		valid.RegisterValidation("address", validAddress)
+		valid.RegisterValidation("tier", validTier)
 	}
 
-	server.setupRouter()
+	if err := server.setupRouter(); err != nil {
+		return nil, fmt.Errorf("Not able to set trusted proxies: %w", err)
+	}
 	return server, nil
 }
 
-func (server *Server) setupRouter() {
+// Supported values of TOKEN_TYPE
+const (
+	tokenTypePaseto       = "paseto"
+	tokenTypePasetoPublic = "paseto_public"
+	tokenTypeJWT          = "jwt"
+	tokenTypeJWTEdDSA     = "jwt_eddsa"
+)
+
+func newTokenMaker(config util.Config) (token.Maker, error) {
+	switch config.TokenType {
+	case tokenTypePaseto, "":
+		previousKeys, err := token.ParsePasetoKeys(config.TokenPreviousKeys)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewPasetoKeyRingMaker(
+			token.PasetoKey{ID: config.TokenKeyID, Key: []byte(config.TokenSymmetricKey)},
+			previousKeys...,
+		)
+	case tokenTypeJWT:
+		return token.NewJWTMaker(config.TokenSymmetricKey)
+	case tokenTypePasetoPublic, tokenTypeJWTEdDSA:
+		privateKey, err := token.ParseEd25519PrivateKey(config.TokenPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		previousKeys, err := token.ParseEd25519PublicKeys(config.TokenPreviousPublicKeys)
+		if err != nil {
+			return nil, err
+		}
+		if config.TokenType == tokenTypePasetoPublic {
+			return token.NewPasetoPublicMaker(config.TokenKeyID, privateKey, previousKeys...)
+		}
+		return token.NewJWTEdDSAMaker(config.TokenKeyID, privateKey, previousKeys...)
+	}
+	return nil, fmt.Errorf("unsupported token type %q", config.TokenType)
+}
+
+func (server *Server) setupRouter() error {
 	router := gin.Default()
+	// Client IPs are only taken from X-Forwarded-For when set by one of our proxies,
+	// anyone else could pick the IP their login failures are counted against
+	err := router.SetTrustedProxies(server.config.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
 	// User Endpoints
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
-
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	router.POST("/users/login/mfa", server.loginUserMfa)
+	router.POST("/users/password/forgot", server.forgotPassword)
+	router.POST("/users/password/reset", server.resetPassword)
+	router.GET("/users/verify_email", server.verifyEmail)
+	router.GET("/.well-known/jwks.json", server.getJWKS)
+	router.POST("/tokens/renew_access", server.renewAccessToken)
+
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocations, nil))
+
+	authRoutes.POST("/users/logout", server.logoutUser)
+	authRoutes.POST("/users/logout_all", server.logoutAllUserSessions)
+	authRoutes.POST("/users/me/totp", server.enrollTOTP)
+	authRoutes.POST("/users/me/totp/confirm", server.confirmTOTP)
+	authRoutes.POST("/users/verify_email/resend", server.resendVerifyEmail)
+	authRoutes.GET("/users/me", server.getProfile)
+	authRoutes.PATCH("/users/me", server.updateProfile)
+	authRoutes.POST("/users/me/password", server.changePassword)
+
+	// API Key Endpoints
+	authRoutes.POST("/api_keys", server.createAPIKey)
+	authRoutes.GET("/api_keys", server.listAPIKeys)
+	authRoutes.DELETE("/api_keys/:id", server.revokeAPIKey)
+
+	// Routes that machine clients may also call with a scoped API key
+	scopedRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocations, server.apiKeys))
+
+	requireVerifiedEmail := verifiedEmailMiddleware(server.store, server.config.RequireVerifiedEmail)
 
 	// Account Endpoints
-	authRoutes.GET("/accounts/:id", server.getAccount)
-	authRoutes.GET("/accounts", server.getListAccount)
-	authRoutes.POST("/accounts", server.createAccount)
-	authRoutes.PUT("/accounts", server.updateAccount)
-	authRoutes.DELETE("/accounts/:id", server.deleteAccount)
+	scopedRoutes.GET("/accounts/:id", scopeMiddleware(util.AccountsReadScope), server.getAccount)
+	scopedRoutes.GET("/accounts", scopeMiddleware(util.AccountsReadScope), server.getListAccount)
+	scopedRoutes.POST("/accounts", scopeMiddleware(util.AccountsWriteScope), requireVerifiedEmail, server.createAccount)
+	scopedRoutes.PATCH("/accounts/:id", scopeMiddleware(util.AccountsWriteScope), server.updateAccount)
+	scopedRoutes.POST("/accounts/:id/close", scopeMiddleware(util.AccountsWriteScope), server.closeAccount)
+	scopedRoutes.GET("/accounts/:id/transfer_limits", scopeMiddleware(util.AccountsReadScope), server.getTransferAllowance)
 
 	// Transaction Endpoints
-	authRoutes.POST("/transactions", server.createTransaction)
+	scopedRoutes.POST("/transactions", scopeMiddleware(util.TransactionsWriteScope), requireVerifiedEmail,
+		idempotencyMiddleware(server.store, server.config.IdempotencyKeyRetention), server.createTransaction)
+	scopedRoutes.POST("/transactions/batch", scopeMiddleware(util.TransactionsWriteScope), requireVerifiedEmail,
+		idempotencyMiddleware(server.store, server.config.IdempotencyKeyRetention), server.createTransferBatch)
+	scopedRoutes.GET("/transactions/batch/:id", scopeMiddleware(util.AccountsReadScope), server.getTransferBatch)
+	scopedRoutes.GET("/transactions/:id", scopeMiddleware(util.AccountsReadScope), server.getTransaction)
+	scopedRoutes.POST("/transactions/:id/reverse", scopeMiddleware(util.TransactionsWriteScope), requireVerifiedEmail,
+		idempotencyMiddleware(server.store, server.config.IdempotencyKeyRetention), server.reverseTransaction)
+
+	// Standing Order Endpoints
+	scopedRoutes.POST("/standing_orders", scopeMiddleware(util.TransactionsWriteScope), requireVerifiedEmail, server.createStandingOrder)
+	scopedRoutes.GET("/standing_orders", scopeMiddleware(util.AccountsReadScope), server.listStandingOrders)
+	scopedRoutes.GET("/standing_orders/:id", scopeMiddleware(util.AccountsReadScope), server.getStandingOrder)
+	scopedRoutes.POST("/standing_orders/:id/cancel", scopeMiddleware(util.TransactionsWriteScope), server.cancelStandingOrder)
+	scopedRoutes.POST("/standing_orders/:id/resume", scopeMiddleware(util.TransactionsWriteScope), server.resumeStandingOrder)
+
+	tellerRoutes := router.Group("/").Use(
+		authMiddleware(server.tokenMaker, server.revocations, nil),
+		roleMiddleware(util.TellerRole, util.AdminRole),
+	)
+
+	// Teller Endpoints
+	tellerRoutes.POST("/accounts/:id/freeze", server.freezeAccount)
+	tellerRoutes.POST("/accounts/:id/unfreeze", server.unfreezeAccount)
+	tellerRoutes.PUT("/accounts/:id/overdraft_limit", server.updateOverdraftLimit)
+	tellerRoutes.POST("/accounts/:id/deposit", server.depositCash)
+	tellerRoutes.POST("/accounts/:id/withdraw", server.withdrawCash)
+	tellerRoutes.PUT("/accounts/:id/transfer_limits", server.updateAccountTransferLimit)
+	tellerRoutes.DELETE("/accounts/:id/transfer_limits", server.deleteAccountTransferLimit)
+
+	adminRoutes := router.Group("/").Use(
+		authMiddleware(server.tokenMaker, server.revocations, nil),
+		roleMiddleware(util.AdminRole),
+	)
+
+	// Admin Endpoints
+	adminRoutes.PUT("/users/:username/role", server.updateUserRole)
+	adminRoutes.PUT("/users/:username/tier", server.updateUserTier)
+	adminRoutes.GET("/transfer_limits", server.listTierTransferLimits)
+	adminRoutes.PUT("/transfer_limits/:tier/:currency", server.updateTierTransferLimit)
+	adminRoutes.DELETE("/login_lockouts/:kind/:subject", server.unlockLogin)
+	adminRoutes.POST("/exchange_rates", server.createExchangeRate)
+	adminRoutes.POST("/reconciliations", server.createReconciliation)
+	adminRoutes.GET("/reconciliations", server.listReconciliations)
+	adminRoutes.GET("/reconciliations/:id", server.getReconciliation)
 
 	//Exchange Endpoints
 	router.GET("/exchange", server.getExchangeRate)
 
 	server.router = router
+	return nil
 }
 
 func (server *Server) Start(address string) error {
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/token"
+	"time"
+)
+
+// Latest runs returned along with a standing order
+const standingOrderRunsShown = 20
+
+var (
+	errStandingOrderNeedsStart   = errors.New("A one-off standing order needs a start_at")
+	errStandingOrderNotRecurring = errors.New("end_at and max_occurrences only apply to recurring standing orders")
+	errStandingOrderStartPast    = errors.New("The first occurrence must be in the future")
+	errStandingOrderEndsEarly    = errors.New("end_at cannot be before the first occurrence")
+	errStandingOrderNoOccurrence = errors.New("The standing order has no occurrence left")
+	errStandingOrderNotOwned     = errors.New("The standing order does not belong to the user")
+	errStandingOrderFinished     = errors.New("The standing order is already completed or cancelled")
+	errStandingOrderNotSuspended = errors.New("Only suspended standing orders can be resumed")
+)
+
+type createStandingOrderRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1,nefield=FromAccountID"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	// Cron expression or RRULE, omitted for a one-off order
+	Schedule string `json:"schedule"`
+	// First payment, recurring orders default to the next time on their schedule
+	StartAt        *time.Time `json:"start_at"`
+	EndAt          *time.Time `json:"end_at"`
+	MaxOccurrences *int32     `json:"max_occurrences" binding:"omitempty,min=1"`
+}
+
+type standingOrderByIdRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listStandingOrderRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=1,max=100"`
+}
+
+type standingOrderResponse struct {
+	ID                        int64      `json:"id"`
+	Username                  string     `json:"username"`
+	FromAccountID             int64      `json:"from_account_id"`
+	ToAccountID               int64      `json:"to_account_id"`
+	Amount                    int64      `json:"amount"`
+	Schedule                  string     `json:"schedule"`
+	Status                    string     `json:"status"`
+	NextRunAt                 time.Time  `json:"next_run_at"`
+	Occurrences               int32      `json:"occurrences"`
+	InsufficientFundsFailures int32      `json:"insufficient_funds_failures"`
+	MaxOccurrences            *int32     `json:"max_occurrences,omitempty"`
+	EndAt                     *time.Time `json:"end_at,omitempty"`
+	CreatedAt                 time.Time  `json:"created_at"`
+}
+
+type standingOrderRunResponse struct {
+	ID            int64     `json:"id"`
+	OccurrenceAt  time.Time `json:"occurrence_at"`
+	Attempt       int32     `json:"attempt"`
+	Status        string    `json:"status"`
+	TransactionID *int64    `json:"transaction_id,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type standingOrderWithRunsResponse struct {
+	StandingOrder standingOrderResponse      `json:"standing_order"`
+	Runs          []standingOrderRunResponse `json:"runs"`
+}
+
+func newStandingOrderResponse(order db.StandingOrder) standingOrderResponse {
+	response := standingOrderResponse{
+		ID:                        order.ID,
+		Username:                  order.Username,
+		FromAccountID:             order.FromAccountID,
+		ToAccountID:               order.ToAccountID,
+		Amount:                    order.Amount,
+		Schedule:                  order.Schedule,
+		Status:                    order.Status,
+		NextRunAt:                 order.NextRunAt,
+		Occurrences:               order.Occurrences,
+		InsufficientFundsFailures: order.InsufficientFundsFailures,
+		CreatedAt:                 order.CreatedAt,
+	}
+	if order.MaxOccurrences.Valid {
+		response.MaxOccurrences = &order.MaxOccurrences.Int32
+	}
+	if order.EndAt.Valid {
+		response.EndAt = &order.EndAt.Time
+	}
+	return response
+}
+
+func newStandingOrderRunResponse(run db.StandingOrderRun) standingOrderRunResponse {
+	response := standingOrderRunResponse{
+		ID:           run.ID,
+		OccurrenceAt: run.OccurrenceAt,
+		Attempt:      run.Attempt,
+		Status:       run.Status,
+		Error:        run.Error,
+		CreatedAt:    run.CreatedAt,
+	}
+	if run.TransactionID.Valid {
+		response.TransactionID = &run.TransactionID.Int64
+	}
+	return response
+}
+
+func (server *Server) createStandingOrder(ctx *gin.Context) {
+	var req createStandingOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	occurrenceAt, err := firstStandingOrderOccurrence(req, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	validFromAccount, fromAccount := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !validFromAccount {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	if authPayload.Username != fromAccount.Username {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("From account does not belong to the user")))
+		return
+	}
+
+	validToAccount, toAccount := server.findAccount(ctx, req.ToAccountID)
+	if !validToAccount {
+		return
+	}
+
+	if util.IsSystemAccount(toAccount.Kind) {
+		err := fmt.Errorf("%w: account [%d] is a system account", db.ErrAccountCannotTransfer, toAccount.ID)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateStandingOrderParams{
+		Username:      authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Schedule:      util.AnchorSchedule(req.Schedule, occurrenceAt),
+		OccurrenceAt:  occurrenceAt,
+	}
+	if req.MaxOccurrences != nil {
+		arg.MaxOccurrences = sql.NullInt32{Int32: *req.MaxOccurrences, Valid: true}
+	}
+	if req.EndAt != nil {
+		arg.EndAt = sql.NullTime{Time: req.EndAt.UTC(), Valid: true}
+	}
+
+	order, err := server.store.CreateStandingOrder(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newStandingOrderResponse(order))
+}
+
+// Checks the schedule and dates of a new order and returns its first occurrence
+func firstStandingOrderOccurrence(req createStandingOrderRequest, now time.Time) (time.Time, error) {
+	var occurrenceAt time.Time
+
+	if req.Schedule == "" {
+		if req.StartAt == nil {
+			return occurrenceAt, errStandingOrderNeedsStart
+		}
+		if req.EndAt != nil || req.MaxOccurrences != nil {
+			return occurrenceAt, errStandingOrderNotRecurring
+		}
+		occurrenceAt = *req.StartAt
+	} else {
+		schedule, err := util.ParseSchedule(req.Schedule)
+		if err != nil {
+			return occurrenceAt, err
+		}
+		if req.StartAt != nil {
+			occurrenceAt = *req.StartAt
+		} else {
+			occurrenceAt = schedule.Next(now)
+		}
+	}
+
+	if !occurrenceAt.After(now) {
+		return occurrenceAt, errStandingOrderStartPast
+	}
+	if req.EndAt != nil && req.EndAt.Before(occurrenceAt) {
+		return occurrenceAt, errStandingOrderEndsEarly
+	}
+	return occurrenceAt.UTC(), nil
+}
+
+func (server *Server) listStandingOrders(ctx *gin.Context) {
+	var req listStandingOrderRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	orders, err := server.store.ListStandingOrders(ctx, db.ListStandingOrdersParams{
+		Username: ctx.MustGet(authPayLoadKey).(*token.Payload).Username,
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := make([]standingOrderResponse, len(orders))
+	for i, order := range orders {
+		response[i] = newStandingOrderResponse(order)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (server *Server) getStandingOrder(ctx *gin.Context) {
+	found, order := server.findStandingOrder(ctx, true)
+	if !found {
+		return
+	}
+
+	runs, err := server.store.ListStandingOrderRuns(ctx, db.ListStandingOrderRunsParams{
+		StandingOrderID: order.ID,
+		Limit:           standingOrderRunsShown,
+		Offset:          0,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := standingOrderWithRunsResponse{
+		StandingOrder: newStandingOrderResponse(order),
+		Runs:          make([]standingOrderRunResponse, len(runs)),
+	}
+	for i, run := range runs {
+		response.Runs[i] = newStandingOrderRunResponse(run)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (server *Server) cancelStandingOrder(ctx *gin.Context) {
+	found, order := server.findStandingOrder(ctx, false)
+	if !found {
+		return
+	}
+
+	order, err := server.store.CancelStandingOrder(ctx, order.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errStandingOrderFinished))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newStandingOrderResponse(order))
+}
+
+// Reactivates a suspended order from its next occurrence. The occurrence that
+// failed is not retried, except for one-off orders which run right away.
+func (server *Server) resumeStandingOrder(ctx *gin.Context) {
+	found, order := server.findStandingOrder(ctx, false)
+	if !found {
+		return
+	}
+
+	if order.Status != util.SuspendedStandingOrderStatus {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errStandingOrderNotSuspended))
+		return
+	}
+
+	now := time.Now().UTC()
+	occurrenceAt := now
+	if order.Schedule != "" {
+		schedule, err := util.ParseSchedule(order.Schedule)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		occurrenceAt = schedule.Next(now)
+	}
+
+	if occurrenceAt.IsZero() || (order.EndAt.Valid && occurrenceAt.After(order.EndAt.Time)) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errStandingOrderNoOccurrence))
+		return
+	}
+
+	order, err := server.store.ResumeStandingOrder(ctx, db.ResumeStandingOrderParams{
+		OccurrenceAt: occurrenceAt,
+		ID:           order.ID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errStandingOrderNotSuspended))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newStandingOrderResponse(order))
+}
+
+// Looks up the standing order in the URI, only its owner may see or change it
+// unless staff are allowed
+func (server *Server) findStandingOrder(ctx *gin.Context, allowStaff bool) (bool, db.StandingOrder) {
+	var req standingOrderByIdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false, db.StandingOrder{}
+	}
+
+	order, err := server.store.GetStandingOrder(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return false, order
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false, order
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	if order.Username != authPayload.Username && !(allowStaff && isStaff(authPayload.Role)) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errStandingOrderNotOwned))
+		return false, order
+	}
+
+	return true, order
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"time"
+)
+
+var (
+	errTransactionNotOwned    = errors.New("The transaction does not involve an account of the user")
+	errTransactionNotReceived = errors.New("Only the recipient of the transaction can refund it")
+)
+
+type transactionByIdRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// An omitted amount refunds all that is left of the transaction
+type reverseTransactionRequest struct {
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+type transactionResponse struct {
+	ID            int64  `json:"id"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	ToAmount      int64  `json:"to_amount"`
+	ExchangeRate  string `json:"exchange_rate,omitempty"`
+	// Set for a refund, the transaction it sends back
+	ReversalOf *int64    `json:"reversal_of,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type transactionWithReversalsResponse struct {
+	Transaction transactionResponse   `json:"transaction"`
+	Reversals   []transactionResponse `json:"reversals"`
+	// In the currency of the from account, always zero for a reversal
+	Refunded   int64 `json:"refunded"`
+	Refundable int64 `json:"refundable"`
+}
+
+func newTransactionResponse(transaction db.Transaction) transactionResponse {
+	response := transactionResponse{
+		ID:            transaction.ID,
+		FromAccountID: transaction.FromAccountID,
+		ToAccountID:   transaction.ToAccountID,
+		Amount:        transaction.Amount,
+		ToAmount:      transaction.ToAmount,
+		ExchangeRate:  transaction.ExchangeRate.String,
+		CreatedAt:     transaction.CreatedAt,
+	}
+	if transaction.ReversalOf.Valid {
+		response.ReversalOf = &transaction.ReversalOf.Int64
+	}
+	return response
+}
+
+// Shows a transaction to the owners of either account, along with its
+// refunds or the transaction it refunds
+func (server *Server) getTransaction(ctx *gin.Context) {
+	found, transaction := server.findTransaction(ctx)
+	if !found {
+		return
+	}
+
+	found, fromAccount := server.findAccount(ctx, transaction.FromAccountID)
+	if !found {
+		return
+	}
+	found, toAccount := server.findAccount(ctx, transaction.ToAccountID)
+	if !found {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	if fromAccount.Username != authPayload.Username && toAccount.Username != authPayload.Username && !isStaff(authPayload.Role) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errTransactionNotOwned))
+		return
+	}
+
+	response := transactionWithReversalsResponse{
+		Transaction: newTransactionResponse(transaction),
+		Reversals:   []transactionResponse{},
+	}
+	if transaction.ReversalOf.Valid {
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+
+	reversals, err := server.store.ListTransactionReversals(ctx, sql.NullInt64{Int64: transaction.ID, Valid: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	for _, reversal := range reversals {
+		response.Reversals = append(response.Reversals, newTransactionResponse(reversal))
+		response.Refunded += reversal.ToAmount
+	}
+	response.Refundable = transaction.Amount - response.Refunded
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Sends a transaction back, fully or in part. The recipient may refund what
+// they received, staff may reverse a mistaken transfer.
+func (server *Server) reverseTransaction(ctx *gin.Context) {
+	found, transaction := server.findTransaction(ctx)
+	if !found {
+		return
+	}
+
+	var req reverseTransactionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	found, toAccount := server.findAccount(ctx, transaction.ToAccountID)
+	if !found {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	if toAccount.Username != authPayload.Username && !isStaff(authPayload.Role) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errTransactionNotReceived))
+		return
+	}
+
+	result, err := server.store.ReverseTransactionTx(ctx, db.ReverseTransactionTxParams{
+		TransactionID: transaction.ID,
+		Amount:        req.Amount,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrTransactionNotReversible) || errors.Is(err, db.ErrReversalExceedsTransaction) ||
+			errors.Is(err, db.ErrAccountCannotTransfer) || errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrCurrencyConversion) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (server *Server) findTransaction(ctx *gin.Context) (bool, db.Transaction) {
+	var req transactionByIdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false, db.Transaction{}
+	}
+
+	transaction, err := server.store.GetTransaction(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return false, transaction
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false, transaction
+	}
+
+	return true, transaction
+}
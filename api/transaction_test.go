@@ -0,0 +1,338 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"testing"
+	"time"
+)
+
+func randomTransaction(fromAccount db.Account, toAccount db.Account) db.Transaction {
+	amount := util.RandomInt(100, 1000)
+	return db.Transaction{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		ToAmount:      amount,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestGetTransactionAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account1.ID = 1
+	account2 := randomAccount(user2.Username)
+	account2.ID = 2
+
+	transaction := randomTransaction(account1, account2)
+	reversal := db.Transaction{
+		ID:            transaction.ID + 1,
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        30,
+		ToAmount:      30,
+		ReversalOf:    sql.NullInt64{Int64: transaction.ID, Valid: true},
+		CreatedAt:     transaction.CreatedAt,
+	}
+
+	testCases := []struct {
+		name          string
+		transaction   db.Transaction
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "OK",
+			transaction: transaction,
+			username:    user1.Username,
+			role:        util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				reversalOf := sql.NullInt64{Int64: transaction.ID, Valid: true}
+				store.EXPECT().ListTransactionReversals(gomock.Any(), gomock.Eq(reversalOf)).Times(1).Return([]db.Transaction{reversal}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transactionWithReversalsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, newTransactionResponse(transaction), rsp.Transaction)
+				require.Equal(t, []transactionResponse{newTransactionResponse(reversal)}, rsp.Reversals)
+				require.Equal(t, int64(30), rsp.Refunded)
+				require.Equal(t, transaction.Amount-30, rsp.Refundable)
+			},
+		},
+		{
+			name:        "Reversal",
+			transaction: reversal,
+			username:    user1.Username,
+			role:        util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(reversal.ID)).Times(1).Return(reversal, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().ListTransactionReversals(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transactionWithReversalsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, transaction.ID, *rsp.Transaction.ReversalOf)
+				require.Empty(t, rsp.Reversals)
+				require.Zero(t, rsp.Refundable)
+			},
+		},
+		{
+			name:        "Staff",
+			transaction: transaction,
+			username:    "teller",
+			role:        util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+				store.EXPECT().ListTransactionReversals(gomock.Any(), gomock.Any()).Times(1).Return([]db.Transaction{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:        "Unauthorized User",
+			transaction: transaction,
+			username:    "unauthorized_user",
+			role:        util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+				store.EXPECT().ListTransactionReversals(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:        "Not Found",
+			transaction: transaction,
+			username:    user1.Username,
+			role:        util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(db.Transaction{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transactions/%d", currTest.transaction.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, currTest.username, currTest.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestReverseTransactionAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account1.ID = 1
+	account2 := randomAccount(user2.Username)
+	account2.ID = 2
+
+	transaction := randomTransaction(account1, account2)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Partial Refund",
+			body:     gin.H{"amount": 50},
+			username: user2.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.ReverseTransactionTxParams{
+					TransactionID: transaction.ID,
+					Amount:        50,
+				}
+				store.EXPECT().ReverseTransactionTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.ReverseTransactionTxResult{
+					Original:   transaction,
+					Refundable: transaction.Amount - 50,
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp db.ReverseTransactionTxResult
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, transaction.Amount-50, rsp.Refundable)
+			},
+		},
+		{
+			name:     "Staff Full Reversal",
+			username: "teller",
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.ReverseTransactionTxParams{
+					TransactionID: transaction.ID,
+				}
+				store.EXPECT().ReverseTransactionTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.ReverseTransactionTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Sender Cannot Reverse",
+			body:     gin.H{"amount": 50},
+			username: user1.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransactionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "Invalid Amount",
+			body:     gin.H{"amount": -1},
+			username: user2.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().ReverseTransactionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Exceeds Transaction",
+			body:     gin.H{"amount": transaction.Amount + 1},
+			username: user2.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransactionTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ReverseTransactionTxResult{}, fmt.Errorf("%w: transaction [%d] has %d left to refund", db.ErrReversalExceedsTransaction, transaction.ID, transaction.Amount))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "Insufficient Funds",
+			username: user2.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransactionTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReverseTransactionTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "Internal Error",
+			username: user2.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransactionTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReverseTransactionTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body []byte
+			if currTest.body != nil {
+				data, err := json.Marshal(currTest.body)
+				require.NoError(t, err)
+				body = data
+			}
+
+			url := fmt.Sprintf("/transactions/%d/reverse", transaction.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, currTest.username, currTest.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/db/util"
 	"simplebank/token"
 )
 
@@ -34,19 +35,38 @@ func (server *Server) createTransaction(ctx *gin.Context) {
 		return
 	}
 
-	validToAccount, _ := server.validAccount(ctx, req.ToAccountID, req.Currency)
+	// The to account may hold another currency, the amount is then converted
+	validToAccount, toAccount := server.findAccount(ctx, req.ToAccountID)
 	if !validToAccount {
 		return
 	}
 
+	// System accounts only move money through ledgered operations
+	if util.IsSystemAccount(toAccount.Kind) {
+		err := fmt.Errorf("%w: account [%d] is a system account", db.ErrAccountCannotTransfer, toAccount.ID)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
 	arg := db.TransactionTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
+		FXSpread:      server.config.FXSpread,
 	}
 
 	transaction, err := server.store.TransactionTx(ctx, arg)
 	if err != nil {
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(http.StatusUnprocessableEntity, transferLimitErrorResponse(limitErr))
+			return
+		}
+		if errors.Is(err, db.ErrAccountCannotTransfer) || errors.Is(err, db.ErrInsufficientFunds) ||
+			errors.Is(err, db.ErrCurrencyConversion) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -55,6 +75,20 @@ func (server *Server) createTransaction(ctx *gin.Context) {
 }
 
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (bool, db.Account) {
+	found, account := server.findAccount(ctx, accountID)
+	if !found {
+		return false, account
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false, account
+	}
+	return true, account
+}
+
+func (server *Server) findAccount(ctx *gin.Context, accountID int64) (bool, db.Account) {
 	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -64,11 +98,5 @@ func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency s
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return false, account
 	}
-
-	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return false, account
-	}
 	return true, account
 }
@@ -0,0 +1,316 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/token"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Most transfers accepted in one batch
+const maxBatchTransfers = 500
+
+var (
+	errBatchTransferEmpty   = errors.New("The batch has no transfers")
+	errBatchTransferTooLong = fmt.Errorf("A batch holds at most %d transfers", maxBatchTransfers)
+	errBatchTransferNoFile  = errors.New("Upload the transfers as a CSV file named file")
+	errTransferBatchOwned   = errors.New("The transfer batch does not belong to the user")
+)
+
+// Transfers come as a JSON list, or as a CSV file along with the other fields
+// in a multipart form
+type batchTransferRequest struct {
+	FromAccountID int64                      `json:"from_account_id" form:"from_account_id" binding:"required,min=1"`
+	Currency      string                     `json:"currency" form:"currency" binding:"required,currency"`
+	Mode          string                     `json:"mode" form:"mode" binding:"required,oneof=all_or_nothing best_effort"`
+	Transfers     []batchTransferLineRequest `json:"transfers" form:"-" binding:"dive"`
+}
+
+type batchTransferLineRequest struct {
+	ToAccountID int64 `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64 `json:"amount" binding:"required,gt=0"`
+}
+
+type transferBatchByIdRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type transferBatchReportRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+type transferBatchLineResponse struct {
+	Line          int32  `json:"line"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Status        string `json:"status"`
+	TransactionID *int64 `json:"transaction_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type transferBatchResponse struct {
+	ID            int64                       `json:"id"`
+	FromAccountID int64                       `json:"from_account_id"`
+	Mode          string                      `json:"mode"`
+	Status        string                      `json:"status"`
+	Succeeded     int32                       `json:"succeeded"`
+	Failed        int32                       `json:"failed"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	Lines         []transferBatchLineResponse `json:"lines"`
+}
+
+func newTransferBatchResponse(batch db.TransferBatch, lines []db.TransferBatchLine) transferBatchResponse {
+	response := transferBatchResponse{
+		ID:            batch.ID,
+		FromAccountID: batch.FromAccountID,
+		Mode:          batch.Mode,
+		Status:        batch.Status,
+		Succeeded:     batch.Succeeded,
+		Failed:        batch.Failed,
+		CreatedAt:     batch.CreatedAt,
+		Lines:         make([]transferBatchLineResponse, len(lines)),
+	}
+	for i, line := range lines {
+		response.Lines[i] = transferBatchLineResponse{
+			Line:        line.Line,
+			ToAccountID: line.ToAccountID,
+			Amount:      line.Amount,
+			Status:      line.Status,
+			Error:       line.Error,
+		}
+		if line.TransactionID.Valid {
+			response.Lines[i].TransactionID = &lines[i].TransactionID.Int64
+		}
+	}
+	return response
+}
+
+// Pays many accounts from one account. Every line is validated before any
+// runs, then the batch either runs as a whole or line by line depending on
+// its mode. The report is stored so it can be downloaded later.
+func (server *Server) createTransferBatch(ctx *gin.Context) {
+	req, err := bindBatchTransferRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	validFromAccount, fromAccount := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !validFromAccount {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	if authPayload.Username != fromAccount.Username {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("From account does not belong to the user")))
+		return
+	}
+
+	arg := db.BatchTransferTxParams{
+		Username:      authPayload.Username,
+		FromAccountID: fromAccount.ID,
+		Mode:          req.Mode,
+		FXSpread:      server.config.FXSpread,
+		Lines:         make([]db.BatchTransferLine, len(req.Transfers)),
+	}
+	for i, transfer := range req.Transfers {
+		arg.Lines[i] = db.BatchTransferLine{
+			ToAccountID: transfer.ToAccountID,
+			Amount:      transfer.Amount,
+		}
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Nothing moved, the report says why
+	status := http.StatusOK
+	if result.Batch.Status == util.FailedBatchStatus {
+		status = http.StatusUnprocessableEntity
+	}
+
+	ctx.JSON(status, newTransferBatchResponse(result.Batch, result.Lines))
+}
+
+// Returns the report of a batch as JSON, or as a CSV download with format=csv
+func (server *Server) getTransferBatch(ctx *gin.Context) {
+	var uriReq transferBatchByIdRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req transferBatchReportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, err := server.store.GetTransferBatch(ctx, uriReq.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	if batch.Username != authPayload.Username && !isStaff(authPayload.Role) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errTransferBatchOwned))
+		return
+	}
+
+	lines, err := server.store.ListTransferBatchLines(ctx, batch.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if req.Format != "csv" {
+		ctx.JSON(http.StatusOK, newTransferBatchResponse(batch, lines))
+		return
+	}
+
+	report, err := transferBatchReportCSV(lines)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"transfer_batch_%d.csv\"", batch.ID))
+	ctx.Data(http.StatusOK, "text/csv", report)
+}
+
+func bindBatchTransferRequest(ctx *gin.Context) (batchTransferRequest, error) {
+	var req batchTransferRequest
+
+	if ctx.ContentType() != gin.MIMEMultipartPOSTForm {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			return req, err
+		}
+	} else {
+		if err := ctx.ShouldBind(&req); err != nil {
+			return req, err
+		}
+
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			return req, errBatchTransferNoFile
+		}
+		file, err := header.Open()
+		if err != nil {
+			return req, err
+		}
+		defer file.Close()
+
+		req.Transfers, err = parseBatchTransferCSV(file)
+		if err != nil {
+			return req, err
+		}
+	}
+
+	if len(req.Transfers) == 0 {
+		return req, errBatchTransferEmpty
+	}
+	if len(req.Transfers) > maxBatchTransfers {
+		return req, errBatchTransferTooLong
+	}
+	return req, nil
+}
+
+// Reads transfers from a CSV file with a to_account_id and an amount column,
+// named in its header row in any order
+func parseBatchTransferCSV(reader io.Reader) ([]batchTransferLineRequest, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errBatchTransferEmpty
+		}
+		return nil, err
+	}
+
+	columns := map[string]int{"to_account_id": -1, "amount": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[name]; ok {
+			columns[name] = i
+		}
+	}
+	for name, index := range columns {
+		if index < 0 {
+			return nil, fmt.Errorf("The CSV file has no %s column", name)
+		}
+	}
+
+	var transfers []batchTransferLineRequest
+	for line := 1; ; line++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return transfers, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line > maxBatchTransfers {
+			return nil, errBatchTransferTooLong
+		}
+
+		toAccountID, err := strconv.ParseInt(strings.TrimSpace(record[columns["to_account_id"]]), 10, 64)
+		if err != nil || toAccountID < 1 {
+			return nil, fmt.Errorf("Line %d has an invalid to_account_id", line)
+		}
+		amount, err := strconv.ParseInt(strings.TrimSpace(record[columns["amount"]]), 10, 64)
+		if err != nil || amount <= 0 {
+			return nil, fmt.Errorf("Line %d has an invalid amount", line)
+		}
+
+		transfers = append(transfers, batchTransferLineRequest{
+			ToAccountID: toAccountID,
+			Amount:      amount,
+		})
+	}
+}
+
+func transferBatchReportCSV(lines []db.TransferBatchLine) ([]byte, error) {
+	var report bytes.Buffer
+	writer := csv.NewWriter(&report)
+
+	rows := [][]string{{"line", "to_account_id", "amount", "status", "transaction_id", "error"}}
+	for _, line := range lines {
+		transactionID := ""
+		if line.TransactionID.Valid {
+			transactionID = strconv.FormatInt(line.TransactionID.Int64, 10)
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(int(line.Line)),
+			strconv.FormatInt(line.ToAccountID, 10),
+			strconv.FormatInt(line.Amount, 10),
+			line.Status,
+			transactionID,
+			line.Error,
+		})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return report.Bytes(), nil
+}
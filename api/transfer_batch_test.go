@@ -0,0 +1,387 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"testing"
+	"time"
+)
+
+func randomTransferBatch(username string, fromAccountID int64, status string) (db.TransferBatch, []db.TransferBatchLine) {
+	batch := db.TransferBatch{
+		ID:            util.RandomInt(1, 1000),
+		Username:      username,
+		FromAccountID: fromAccountID,
+		Mode:          util.BestEffortBatchMode,
+		Status:        status,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+
+	lines := []db.TransferBatchLine{
+		{
+			ID:            1,
+			BatchID:       batch.ID,
+			Line:          1,
+			ToAccountID:   fromAccountID + 1,
+			Amount:        100,
+			Status:        util.SucceededBatchLine,
+			TransactionID: sql.NullInt64{Int64: 7, Valid: true},
+		},
+		{
+			ID:          2,
+			BatchID:     batch.ID,
+			Line:        2,
+			ToAccountID: fromAccountID + 2,
+			Amount:      50,
+			Status:      util.FailedBatchLine,
+			Error:       "insufficient funds: account [1] has 0 available",
+		},
+	}
+	batch.Succeeded, batch.Failed = 1, 1
+
+	return batch, lines
+}
+
+func TestCreateTransferBatchAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.ID = 1
+	account.Currency = util.USD
+
+	batch, lines := randomTransferBatch(user.Username, account.ID, util.PartiallyCompletedBatchStatus)
+	failedBatch := batch
+	failedBatch.Status = util.FailedBatchStatus
+
+	arg := db.BatchTransferTxParams{
+		Username:      user.Username,
+		FromAccountID: account.ID,
+		Mode:          util.BestEffortBatchMode,
+		Lines: []db.BatchTransferLine{
+			{ToAccountID: 2, Amount: 100},
+			{ToAccountID: 3, Amount: 50},
+		},
+	}
+
+	jsonBody := gin.H{
+		"from_account_id": account.ID,
+		"currency":        util.USD,
+		"mode":            util.BestEffortBatchMode,
+		"transfers": []gin.H{
+			{"to_account_id": 2, "amount": 100},
+			{"to_account_id": 3, "amount": 50},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		csv           string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "JSON",
+			body:     jsonBody,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.BatchTransferTxResult{Batch: batch, Lines: lines}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferBatchResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, newTransferBatchResponse(batch, lines), rsp)
+				require.Equal(t, int64(7), *rsp.Lines[0].TransactionID)
+				require.Nil(t, rsp.Lines[1].TransactionID)
+			},
+		},
+		{
+			name:     "CSV",
+			csv:      "amount,to_account_id\n100,2\n 50, 3\n",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.BatchTransferTxResult{Batch: batch, Lines: lines}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Batch Failed",
+			body:     jsonBody,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.BatchTransferTxResult{Batch: failedBatch, Lines: lines}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				var rsp transferBatchResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, util.FailedBatchStatus, rsp.Status)
+			},
+		},
+		{
+			name:     "CSV Invalid Amount",
+			csv:      "to_account_id,amount\n2,ten\n",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "CSV Missing Column",
+			csv:      "account,amount\n2,100\n",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Empty Batch",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        util.USD,
+				"mode":            util.AllOrNothingBatchMode,
+				"transfers":       []gin.H{},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Mode",
+			body: gin.H{
+				"from_account_id": account.ID,
+				"currency":        util.USD,
+				"mode":            "some",
+				"transfers":       []gin.H{{"to_account_id": 2, "amount": 100}},
+			},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Unauthorized User",
+			body:     jsonBody,
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "Internal Error",
+			body:     jsonBody,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body io.Reader
+			contentType := gin.MIMEJSON
+			if currTest.csv != "" {
+				var form bytes.Buffer
+				writer := multipart.NewWriter(&form)
+				require.NoError(t, writer.WriteField("from_account_id", fmt.Sprint(account.ID)))
+				require.NoError(t, writer.WriteField("currency", util.USD))
+				require.NoError(t, writer.WriteField("mode", util.BestEffortBatchMode))
+				file, err := writer.CreateFormFile("file", "transfers.csv")
+				require.NoError(t, err)
+				_, err = file.Write([]byte(currTest.csv))
+				require.NoError(t, err)
+				require.NoError(t, writer.Close())
+
+				body = &form
+				contentType = writer.FormDataContentType()
+			} else {
+				data, err := json.Marshal(currTest.body)
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			}
+
+			request, err := http.NewRequest(http.MethodPost, "/transactions/batch", body)
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", contentType)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, currTest.username, util.CustomerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransferBatchAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	batch, lines := randomTransferBatch(user.Username, 1, util.PartiallyCompletedBatchStatus)
+
+	testCases := []struct {
+		name          string
+		query         string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "JSON",
+			username: user.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchLines(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(lines, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferBatchResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, newTransferBatchResponse(batch, lines), rsp)
+			},
+		},
+		{
+			name:     "CSV",
+			query:    "?format=csv",
+			username: "teller",
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchLines(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(lines, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), "attachment")
+
+				rows, err := csv.NewReader(recorder.Body).ReadAll()
+				require.NoError(t, err)
+				require.Equal(t, [][]string{
+					{"line", "to_account_id", "amount", "status", "transaction_id", "error"},
+					{"1", "2", "100", util.SucceededBatchLine, "7", ""},
+					{"2", "3", "50", util.FailedBatchLine, "", lines[1].Error},
+				}, rows)
+			},
+		},
+		{
+			name:     "Invalid Format",
+			query:    "?format=xml",
+			username: user.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Unauthorized User",
+			username: "unauthorized_user",
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().ListTransferBatchLines(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "Not Found",
+			username: user.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(db.TransferBatch{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transactions/batch/%d%s", batch.ID, currTest.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, currTest.username, currTest.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
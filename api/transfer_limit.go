@@ -0,0 +1,197 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"time"
+)
+
+var errNoAccountTransferLimits = errors.New("The account has no transfer limits of its own")
+
+type tierTransferLimitUriRequest struct {
+	Tier     string `uri:"tier" binding:"required,tier"`
+	Currency string `uri:"currency" binding:"required,currency"`
+}
+
+// Omitted limits do not apply, or fall back to the tier for an account
+type transferLimitRequest struct {
+	PerTransfer *int64 `json:"per_transfer" binding:"omitempty,gt=0"`
+	Daily       *int64 `json:"daily" binding:"omitempty,gt=0"`
+	Monthly     *int64 `json:"monthly" binding:"omitempty,gt=0"`
+}
+
+type transferLimitResponse struct {
+	Tier        string    `json:"tier,omitempty"`
+	AccountID   int64     `json:"account_id,omitempty"`
+	Currency    string    `json:"currency"`
+	PerTransfer *int64    `json:"per_transfer,omitempty"`
+	Daily       *int64    `json:"daily,omitempty"`
+	Monthly     *int64    `json:"monthly,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func newTransferLimitResponse(limit db.TransferLimit) transferLimitResponse {
+	response := transferLimitResponse{
+		Tier:      limit.Tier.String,
+		AccountID: limit.AccountID.Int64,
+		Currency:  limit.Currency,
+		UpdatedAt: limit.UpdatedAt,
+	}
+	if limit.PerTransfer.Valid {
+		response.PerTransfer = &limit.PerTransfer.Int64
+	}
+	if limit.Daily.Valid {
+		response.Daily = &limit.Daily.Int64
+	}
+	if limit.Monthly.Valid {
+		response.Monthly = &limit.Monthly.Int64
+	}
+	return response
+}
+
+// Reports the broken limit along with the message, so clients can show what is left
+func transferLimitErrorResponse(err *db.TransferLimitError) gin.H {
+	return gin.H{
+		"error":     err.Error(),
+		"limit":     err.Limit,
+		"currency":  err.Currency,
+		"max":       err.Max,
+		"remaining": err.Remaining,
+	}
+}
+
+func nullLimit(limit *int64) sql.NullInt64 {
+	if limit == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *limit, Valid: true}
+}
+
+func (server *Server) listTierTransferLimits(ctx *gin.Context) {
+	limits, err := server.store.ListTierTransferLimits(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := make([]transferLimitResponse, len(limits))
+	for i, limit := range limits {
+		response[i] = newTransferLimitResponse(limit)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (server *Server) updateTierTransferLimit(ctx *gin.Context) {
+	var uriReq tierTransferLimitUriRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req transferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	limit, err := server.store.UpsertTierTransferLimit(ctx, db.UpsertTierTransferLimitParams{
+		Tier:        sql.NullString{String: uriReq.Tier, Valid: true},
+		Currency:    uriReq.Currency,
+		PerTransfer: nullLimit(req.PerTransfer),
+		Daily:       nullLimit(req.Daily),
+		Monthly:     nullLimit(req.Monthly),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferLimitResponse(limit))
+}
+
+// Shows the limits that apply to the account and what is left of them today
+// and this month
+func (server *Server) getTransferAllowance(ctx *gin.Context) {
+	found, account := server.findAccountByUri(ctx)
+	if !found {
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+	if account.Username != authPayload.Username && !isStaff(authPayload.Role) {
+		err := errors.New("The account does not belong to the user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	allowance, err := server.store.GetTransferAllowance(ctx, account)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, allowance)
+}
+
+func (server *Server) updateAccountTransferLimit(ctx *gin.Context) {
+	found, account := server.findAccountByUri(ctx)
+	if !found {
+		return
+	}
+
+	var req transferLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	limit, err := server.store.UpsertAccountTransferLimit(ctx, db.UpsertAccountTransferLimitParams{
+		AccountID:   sql.NullInt64{Int64: account.ID, Valid: true},
+		Currency:    account.Currency,
+		PerTransfer: nullLimit(req.PerTransfer),
+		Daily:       nullLimit(req.Daily),
+		Monthly:     nullLimit(req.Monthly),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferLimitResponse(limit))
+}
+
+// Puts the account back on the limits of the tier of its owner
+func (server *Server) deleteAccountTransferLimit(ctx *gin.Context) {
+	var req accountByIdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	count, err := server.store.DeleteAccountTransferLimit(ctx, sql.NullInt64{Int64: req.ID, Valid: true})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if count == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errNoAccountTransferLimits))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"account_id": req.ID})
+}
+
+func (server *Server) findAccountByUri(ctx *gin.Context) (bool, db.Account) {
+	var req accountByIdRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false, db.Account{}
+	}
+
+	return server.findAccount(ctx, req.ID)
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"testing"
+	"time"
+)
+
+func TestUpdateTierTransferLimitAPI(t *testing.T) {
+	limit := db.TransferLimit{
+		ID:        1,
+		Tier:      sql.NullString{String: util.StandardTier, Valid: true},
+		Currency:  util.USD,
+		Daily:     sql.NullInt64{Int64: 5000, Valid: true},
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	testCases := []struct {
+		name          string
+		url           string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			url:  "/transfer_limits/standard/USD",
+			body: gin.H{"daily": 5000},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertTierTransferLimitParams{
+					Tier:     sql.NullString{String: util.StandardTier, Valid: true},
+					Currency: util.USD,
+					Daily:    sql.NullInt64{Int64: 5000, Valid: true},
+				}
+				store.EXPECT().UpsertTierTransferLimit(gomock.Any(), gomock.Eq(arg)).Times(1).Return(limit, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferLimitResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, newTransferLimitResponse(limit), rsp)
+				require.Nil(t, rsp.PerTransfer)
+			},
+		},
+		{
+			name: "Invalid Tier",
+			url:  "/transfer_limits/platinum/USD",
+			body: gin.H{"daily": 5000},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertTierTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Invalid Limit",
+			url:  "/transfer_limits/standard/USD",
+			body: gin.H{"per_transfer": 0},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertTierTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "Teller Forbidden",
+			url:  "/transfer_limits/standard/USD",
+			body: gin.H{"daily": 5000},
+			role: util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertTierTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(currTest.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPut, currTest.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, "admin", currTest.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAccountTransferLimitAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	perTransfer := int64(1000)
+	daily := int64(5000)
+	dailyRemaining := int64(1200)
+	allowance := db.TransferAllowance{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		PerTransfer:    &perTransfer,
+		Daily:          &daily,
+		DailyRemaining: &dailyRemaining,
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		body          gin.H
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Get Allowance",
+			method:   http.MethodGet,
+			username: user.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetTransferAllowance(gomock.Any(), gomock.Eq(account)).Times(1).Return(allowance, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp db.TransferAllowance
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, allowance, rsp)
+				require.Nil(t, rsp.Monthly)
+			},
+		},
+		{
+			name:     "Get Allowance Of Another User",
+			method:   http.MethodGet,
+			username: "unauthorized_user",
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetTransferAllowance(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "Update",
+			method:   http.MethodPut,
+			body:     gin.H{"per_transfer": 1000, "monthly": 20000},
+			username: "teller",
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.UpsertAccountTransferLimitParams{
+					AccountID:   sql.NullInt64{Int64: account.ID, Valid: true},
+					Currency:    account.Currency,
+					PerTransfer: sql.NullInt64{Int64: 1000, Valid: true},
+					Monthly:     sql.NullInt64{Int64: 20000, Valid: true},
+				}
+				store.EXPECT().UpsertAccountTransferLimit(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferLimit{
+					ID:          2,
+					AccountID:   arg.AccountID,
+					Currency:    arg.Currency,
+					PerTransfer: arg.PerTransfer,
+					Monthly:     arg.Monthly,
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferLimitResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, account.ID, rsp.AccountID)
+				require.Empty(t, rsp.Tier)
+				require.Nil(t, rsp.Daily)
+			},
+		},
+		{
+			name:     "Customer Cannot Update",
+			method:   http.MethodPut,
+			body:     gin.H{"daily": 1000000},
+			username: user.Username,
+			role:     util.CustomerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertAccountTransferLimit(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "Delete",
+			method:   http.MethodDelete,
+			username: "teller",
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				accountID := sql.NullInt64{Int64: account.ID, Valid: true}
+				store.EXPECT().DeleteAccountTransferLimit(gomock.Any(), gomock.Eq(accountID)).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Delete Without Limits",
+			method:   http.MethodDelete,
+			username: "teller",
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteAccountTransferLimit(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		currTest := testCases[i]
+
+		t.Run(currTest.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			currTest.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(currTest.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/transfer_limits", account.ID)
+			request, err := http.NewRequest(currTest.method, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, currTest.username, currTest.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			currTest.checkResponse(t, recorder)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -49,7 +50,7 @@ func TestTransactionAPI(t *testing.T) {
 				"currency":        util.HKD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, time.Minute)
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(mockAccount1, nil)
@@ -75,7 +76,7 @@ func TestTransactionAPI(t *testing.T) {
 				"currency":        util.HKD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authTypeBearer, mockCurrencyMisMatchAccount.Username, time.Minute)
+				addAuth(t, request, tokenMaker, authTypeBearer, mockCurrencyMisMatchAccount.Username, util.CustomerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockCurrencyMisMatchAccount.ID)).Times(1).Return(mockCurrencyMisMatchAccount, nil)
@@ -93,7 +94,7 @@ func TestTransactionAPI(t *testing.T) {
 			},
 		},
 		{
-			name: "From Account Currency MisMatch",
+			name: "Cross Currency",
 			body: gin.H{
 				"from_account_id": mockAccount1.ID,
 				"to_account_id":   mockCurrencyMisMatchAccount.ID,
@@ -101,7 +102,7 @@ func TestTransactionAPI(t *testing.T) {
 				"currency":        util.HKD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, time.Minute)
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(mockAccount1, nil)
@@ -112,10 +113,36 @@ func TestTransactionAPI(t *testing.T) {
 					ToAccountID:   mockCurrencyMisMatchAccount.ID,
 					Amount:        mockAmount,
 				}
-				store.EXPECT().TransactionTx(gomock.Any(), gomock.Eq(arg)).Times(0)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Eq(arg)).Times(1)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Currency Conversion Failed",
+			body: gin.H{
+				"from_account_id": mockAccount1.ID,
+				"to_account_id":   mockCurrencyMisMatchAccount.ID,
+				"amount":          mockAmount,
+				"currency":        util.HKD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(mockAccount1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockCurrencyMisMatchAccount.ID)).Times(1).Return(mockCurrencyMisMatchAccount, nil)
+
+				arg := db.TransactionTxParams{
+					FromAccountID: mockAccount1.ID,
+					ToAccountID:   mockCurrencyMisMatchAccount.ID,
+					Amount:        mockAmount,
+				}
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransactionTxResult{}, db.ErrCurrencyConversion)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
 			},
 		},
 		{
@@ -127,7 +154,7 @@ func TestTransactionAPI(t *testing.T) {
 				"currency":        "BTC",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, time.Minute)
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(0).Return(mockAccount1, nil)
@@ -146,7 +173,7 @@ func TestTransactionAPI(t *testing.T) {
 				"currency":        mockCurrency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, time.Minute)
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
@@ -166,7 +193,7 @@ func TestTransactionAPI(t *testing.T) {
 				"currency":        mockCurrency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, time.Minute)
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(mockAccount1, nil)
@@ -186,7 +213,7 @@ func TestTransactionAPI(t *testing.T) {
 				"currency":        mockCurrency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, time.Minute)
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
@@ -205,7 +232,7 @@ func TestTransactionAPI(t *testing.T) {
 				"currency":        mockCurrency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, time.Minute)
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(mockAccount1, nil)
@@ -216,6 +243,110 @@ func TestTransactionAPI(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "Frozen Account",
+			body: gin.H{
+				"from_account_id": mockAccount1.ID,
+				"to_account_id":   mockAccount2.ID,
+				"amount":          mockAmount,
+				"currency":        util.HKD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(mockAccount1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount2.ID)).Times(1).Return(mockAccount2, nil)
+
+				err := fmt.Errorf("%w: account [%d] is frozen", db.ErrAccountCannotTransfer, mockAccount2.ID)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransactionTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "To System Account",
+			body: gin.H{
+				"from_account_id": mockAccount1.ID,
+				"to_account_id":   mockAccount2.ID,
+				"amount":          mockAmount,
+				"currency":        util.HKD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				cashAccount := mockAccount2
+				cashAccount.Kind = util.CashAccountKind
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(mockAccount1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount2.ID)).Times(1).Return(cashAccount, nil)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "Insufficient Funds",
+			body: gin.H{
+				"from_account_id": mockAccount1.ID,
+				"to_account_id":   mockAccount2.ID,
+				"amount":          mockAmount,
+				"currency":        util.HKD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(mockAccount1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount2.ID)).Times(1).Return(mockAccount2, nil)
+
+				err := fmt.Errorf("%w: account [%d] has 0 available", db.ErrInsufficientFunds, mockAccount1.ID)
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransactionTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "Transfer Limit Exceeded",
+			body: gin.H{
+				"from_account_id": mockAccount1.ID,
+				"to_account_id":   mockAccount2.ID,
+				"amount":          mockAmount,
+				"currency":        util.HKD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, authTypeBearer, mockAccount1.Username, util.CustomerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount1.ID)).Times(1).Return(mockAccount1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(mockAccount2.ID)).Times(1).Return(mockAccount2, nil)
+
+				err := &db.TransferLimitError{
+					AccountID: mockAccount1.ID,
+					Limit:     db.DailyLimit,
+					Currency:  util.HKD,
+					Max:       100,
+					Remaining: 5,
+				}
+				store.EXPECT().TransactionTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransactionTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				var rsp struct {
+					Limit     string `json:"limit"`
+					Remaining int64  `json:"remaining"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, db.DailyLimit, rsp.Limit)
+				require.Equal(t, int64(5), rsp.Remaining)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -227,6 +358,7 @@ func TestTransactionAPI(t *testing.T) {
 
 			store := mockdb.NewMockStore(controller)
 			testCase.buildStubs(store)
+			allowAllTokens(store)
 
 			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
@@ -2,17 +2,26 @@ package api
 
 import (
 	"database/sql"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"log"
+	"math"
 	"net/http"
 	db "simplebank/db/sqlc"
 	"simplebank/db/util"
+	"simplebank/token"
 	"time"
 )
 
 type loginUserResponse struct {
-	AccessToken string       `json:"access_token"`
-	User        userResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
 }
 
 type loginUserRequest struct {
@@ -20,41 +29,62 @@ type loginUserRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 type createUserRequest struct {
 	Username  string `json:"username" binding:"required,alphanum"`
-	Password  string `json:"password" binding:"required,min=6"`
+	Password  string `json:"password" binding:"required"`
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 	Email     string `json:"email" binding:"required,email"`
 }
 
-type createUserRequestWithNumber struct {
-	Username      string `json:"username" binding:"required,alphanum"`
-	Password      string `json:"password" binding:"required,min=6"`
-	FirstName     string `json:"first_name" binding:"required"`
-	LastName      string `json:"last_name" binding:"required"`
-	Email         string `json:"email" binding:"required,email"`
-	ContactNumber string `json:"contact_number"`
-	Address       string `json:"address"`
+type unlockLoginRequest struct {
+	Kind    string `uri:"kind" binding:"required,oneof=username ip"`
+	Subject string `uri:"subject" binding:"required"`
+}
+
+type updateUserRoleUriRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,role"`
+}
+
+type updateUserTierRequest struct {
+	Tier string `json:"tier" binding:"required,tier"`
 }
 
 type userResponse struct {
-	Username  string    `json:"username"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Email     string    `json:"email"`
-	UpdatedAt time.Time `json:"updated_at"`
-	CreatedAt time.Time `json:"created_at"`
+	Username        string    `json:"username"`
+	Role            string    `json:"role"`
+	Tier            string    `json:"tier"`
+	FirstName       string    `json:"first_name"`
+	LastName        string    `json:"last_name"`
+	Email           string    `json:"email"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	ContactNumber   string    `json:"contact_number,omitempty"`
+	Address         string    `json:"address,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 func newUserResponse(user db.User) userResponse {
 	return userResponse{
-		Username:  user.Username,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		Username:        user.Username,
+		Role:            user.Role,
+		Tier:            user.Tier,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Email:           user.Email,
+		IsEmailVerified: user.IsEmailVerified,
+		ContactNumber:   user.ContactNumber.String,
+		Address:         user.Address.String,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}
 }
 
@@ -65,10 +95,24 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	clientIP := ctx.ClientIP()
+
+	lockedUntil, err := server.loginThrottle.lockedUntil(ctx, req.Username, clientIP)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if retryAfter := time.Until(lockedUntil); retryAfter > 0 {
+		ctx.Header("Retry-After", fmt.Sprint(int64(math.Ceil(retryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errLoginLocked))
+		return
+	}
+
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			server.recordLoginFailure(ctx, req.Username, clientIP, http.StatusNotFound, err)
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -77,28 +121,114 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	err = util.ValidPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		server.recordLoginFailure(ctx, req.Username, clientIP, http.StatusUnauthorized, err)
 		return
 	}
 
-	accessToken, err := server.tokenMaker.CreateToken(
-		user.Username,
-		server.config.AccessTokenDuration,
-	)
+	user = server.upgradePasswordHash(ctx, user, req.Password)
 
+	// The failures are only cleared once the second factor passes too
+	if user.TotpEnabled {
+		server.startMfaChallenge(ctx, user)
+		return
+	}
+
+	err = server.loginThrottle.recordSuccess(ctx, user.Username)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	rsp := loginUserResponse{
-		AccessToken: accessToken,
-		User:        newUserResponse(user),
+	rsp, err := server.createUserSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
 	ctx.JSON(http.StatusOK, rsp)
 }
 
+// Re-hashes the password when its hash was made with an older algorithm or
+// cost. The login goes ahead with the old hash if this fails.
+func (server *Server) upgradePasswordHash(ctx *gin.Context, user db.User, password string) db.User {
+	if !server.passwordHasher.NeedsRehash(user.HashedPassword) {
+		return user
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("cannot re-hash password of %s: %v", user.Username, err)
+		return user
+	}
+
+	upgraded, err := server.store.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		Username:       user.Username,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		log.Printf("cannot store re-hashed password of %s: %v", user.Username, err)
+		return user
+	}
+	return upgraded
+}
+
+// Counts the failed login before responding with the original error
+func (server *Server) recordLoginFailure(ctx *gin.Context, username string, clientIP string, status int, loginErr error) {
+	err := server.loginThrottle.recordFailure(ctx, username, clientIP)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(status, errorResponse(loginErr))
+}
+
+// Issues an access token and a refresh token backed by a new session
+func (server *Server) createUserSession(ctx *gin.Context, user db.User) (loginUserResponse, error) {
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.AccessToken,
+		server.config.AccessTokenDuration,
+	)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.RefreshToken,
+		server.config.RefreshTokenDuration,
+	)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	rsp := loginUserResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newUserResponse(user),
+	}
+	return rsp, nil
+}
+
 func (server *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -106,7 +236,12 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := util.HashPassword(req.Password)
+	if err := server.passwordPolicy.Validate(req.Password); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, err := server.passwordHasher.Hash(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -135,7 +270,151 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
+	// The user can ask for another email if this one does not arrive
+	err = server.sendVerifyEmail(ctx, user)
+	if err != nil {
+		log.Printf("cannot send verification email to %s: %v", user.Username, err)
+	}
+
 	response := newUserResponse(user)
 
 	ctx.JSON(http.StatusOK, response)
 }
+
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if refreshPayload.Type != token.RefreshToken {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errNotRefreshToken))
+		return
+	}
+
+	if refreshPayload.Username != authPayload.Username {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errSessionMismatch))
+		return
+	}
+
+	err = server.store.BlockSession(ctx, db.BlockSessionParams{
+		ID:       refreshPayload.ID,
+		Username: authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	for _, payload := range []*token.Payload{authPayload, refreshPayload} {
+		err = server.revocations.revoke(ctx, payload)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, "Logout Successed.")
+}
+
+func (server *Server) logoutAllUserSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	err := server.revocations.revokeAll(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, "Logout Successed.")
+}
+
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uriReq updateUserRoleUriRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		Username: uriReq.Username,
+		Role:     req.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Tokens carry the role, so force the user to log in again to pick up the new one
+	err = server.revocations.revokeAll(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// Tiers decide the default transfer limits of the accounts of the user
+func (server *Server) updateUserTier(ctx *gin.Context) {
+	var uriReq updateUserRoleUriRequest
+	if err := ctx.ShouldBindUri(&uriReq); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateUserTierRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserTier(ctx, db.UpdateUserTierParams{
+		Username: uriReq.Username,
+		Tier:     req.Tier,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (server *Server) unlockLogin(ctx *gin.Context) {
+	var req unlockLoginRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	err := server.loginThrottle.unlock(ctx, req.Kind, req.Subject)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, "Unlock Successed.")
+}
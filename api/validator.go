@@ -11,3 +11,31 @@ var validCurrency validator.Func = func(fleidLevel validator.FieldLevel) bool {
 	}
 	return false
 }
+
+var validRole validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if role, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedRole(role)
+	}
+	return false
+}
+
+var validScope validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if scope, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedScope(scope)
+	}
+	return false
+}
+
+var validAddress validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if address, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsValidAddress(address)
+	}
+	return false
+}
+
+var validTier validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if tier, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsSupportedTier(tier)
+	}
+	return false
+}
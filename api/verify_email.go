@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/url"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/mail"
+	"simplebank/token"
+	"time"
+)
+
+// Entropy of an email verification token in bytes
+const verifyEmailTokenSize = 32
+
+var (
+	errInvalidVerifyToken   = errors.New("Invalid email verification token")
+	errVerifyTokenExpired   = errors.New("Email verification token has expired")
+	errEmailAlreadyVerified = errors.New("Email is already verified")
+)
+
+type verifyEmailRequest struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// Opened from the link in the verification email
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	verifyToken, err := server.store.GetVerifyEmail(ctx, util.HashToken(req.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidVerifyToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if verifyToken.UsedAt.Valid {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(db.ErrVerifyEmailUsed))
+		return
+	}
+
+	if time.Now().After(verifyToken.ExpiresAt) {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errVerifyTokenExpired))
+		return
+	}
+
+	user, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		ID:       verifyToken.ID,
+		Username: verifyToken.Username,
+		Email:    verifyToken.Email,
+	})
+	if err != nil {
+		switch err {
+		case db.ErrVerifyEmailUsed:
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		case sql.ErrNoRows:
+			// The user changed their email after the token was sent
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidVerifyToken))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (server *Server) resendVerifyEmail(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authPayLoadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsEmailVerified {
+		ctx.JSON(http.StatusConflict, errorResponse(errEmailAlreadyVerified))
+		return
+	}
+
+	err = server.sendVerifyEmail(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, "Verification Email Sent.")
+}
+
+// Stores a new verification token for the current email of the user and mails the link
+func (server *Server) sendVerifyEmail(ctx context.Context, user db.User) error {
+	verifyToken, err := util.GenerateSecureToken(verifyEmailTokenSize)
+	if err != nil {
+		return err
+	}
+
+	_, err = server.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:    user.Username,
+		Email:       user.Email,
+		HashedToken: util.HashToken(verifyToken),
+		ExpiresAt:   time.Now().Add(server.config.VerifyEmailDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	return server.mailer.SendEmail(ctx, newVerifyEmail(user, server.config, verifyToken))
+}
+
+func newVerifyEmail(user db.User, config util.Config, verifyToken string) mail.Email {
+	link := config.VerifyEmailURL + "?token=" + url.QueryEscape(verifyToken)
+
+	body := fmt.Sprintf(
+		"Hi %s,\n\nThanks for signing up to Simple Bank. Please confirm your email address by opening the link below within %s.\n\n%s\n",
+		user.FirstName,
+		config.VerifyEmailDuration,
+		link,
+	)
+
+	return mail.Email{
+		To:      []string{user.Email},
+		Subject: "Verify your Simple Bank email address",
+		Body:    body,
+	}
+}
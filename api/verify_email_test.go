@@ -0,0 +1,300 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"simplebank/mail"
+	"testing"
+	"time"
+)
+
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	rawToken := util.RandomString(32)
+
+	validToken := db.VerifyEmail{
+		ID:          util.RandomInt(1, 1000),
+		Username:    user.Username,
+		Email:       user.Email,
+		HashedToken: util.HashToken(rawToken),
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "token=" + url.QueryEscape(rawToken),
+			buildStubs: func(store *mockdb.MockStore) {
+				verifiedUser := user
+				verifiedUser.IsEmailVerified = true
+
+				store.EXPECT().GetVerifyEmail(gomock.Any(), gomock.Eq(util.HashToken(rawToken))).Times(1).Return(validToken, nil)
+
+				arg := db.VerifyEmailTxParams{
+					ID:       validToken.ID,
+					Username: user.Username,
+					Email:    user.Email,
+				}
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(verifiedUser, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp userResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.IsEmailVerified)
+			},
+		},
+		{
+			name:  "MissingToken",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "UnknownToken",
+			query: "token=" + url.QueryEscape(rawToken),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetVerifyEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.VerifyEmail{}, sql.ErrNoRows)
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "UsedToken",
+			query: "token=" + url.QueryEscape(rawToken),
+			buildStubs: func(store *mockdb.MockStore) {
+				usedToken := validToken
+				usedToken.UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().GetVerifyEmail(gomock.Any(), gomock.Any()).Times(1).Return(usedToken, nil)
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "ExpiredToken",
+			query: "token=" + url.QueryEscape(rawToken),
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredToken := validToken
+				expiredToken.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().GetVerifyEmail(gomock.Any(), gomock.Any()).Times(1).Return(expiredToken, nil)
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "EmailChanged",
+			query: "token=" + url.QueryEscape(rawToken),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetVerifyEmail(gomock.Any(), gomock.Any()).Times(1).Return(validToken, nil)
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			testCase.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users/verify_email?"+testCase.query, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResendVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	verifiedUser, _ := randomUser(t)
+	verifiedUser.IsEmailVerified = true
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, sentEmails string)
+	}{
+		{
+			name: "OK",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, sentEmails string) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, sentEmails, "To: "+user.Email)
+				require.Contains(t, sentEmails, "/users/verify_email?token=")
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			user: verifiedUser,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(verifiedUser.Username)).Times(1).Return(verifiedUser, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, sentEmails string) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Empty(t, sentEmails)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			testCase.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			server.config.VerifyEmailURL = "http://localhost/users/verify_email"
+			var sentEmails bytes.Buffer
+			server.mailer = mail.NewLogMailer(&sentEmails, "test@simplebank.local")
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/verify_email/resend", nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, testCase.user.Username, util.CustomerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(recorder, sentEmails.String())
+		})
+	}
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	user, _ := randomUser(t)
+	verifiedUser, _ := randomUser(t)
+	verifiedUser.IsEmailVerified = true
+
+	testCases := []struct {
+		name          string
+		required      bool
+		user          db.User
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "PolicyDisabled",
+			required: false,
+			user:     user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Verified",
+			required: true,
+			user:     verifiedUser,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(verifiedUser.Username)).Times(1).Return(verifiedUser, nil)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotVerified",
+			required: true,
+			user:     user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			required: true,
+			user:     user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			defer controller.Finish()
+
+			store := mockdb.NewMockStore(controller)
+			testCase.buildStubs(store)
+			allowAllTokens(store)
+
+			server := newTestServer(t, store)
+			server.config.RequireVerifiedEmail = testCase.required
+			require.NoError(t, server.setupRouter())
+			recorder := httptest.NewRecorder()
+
+			res, err := json.Marshal(gin.H{"currency": util.USD, "location": util.RandomLocation()})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(res))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, authTypeBearer, testCase.user.Username, util.CustomerRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			testCase.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package cronjob
+
+import (
+	"context"
+	"log"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+// DormantAccountJob marks active accounts without any records during the
+// inactivity period as dormant
+type DormantAccountJob struct {
+	Store            db.Store
+	InactivityPeriod time.Duration
+}
+
+func (g DormantAccountJob) Run() {
+	inactiveSince := time.Now().Add(-g.InactivityPeriod)
+
+	count, err := g.Store.MarkDormantAccounts(context.Background(), inactiveSince)
+	if err != nil {
+		log.Printf("cannot mark dormant accounts: %v", err)
+		return
+	}
+
+	log.Printf("marked %d accounts inactive since %s as dormant", count, inactiveSince.Format(time.RFC3339))
+}
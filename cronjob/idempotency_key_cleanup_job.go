@@ -0,0 +1,23 @@
+package cronjob
+
+import (
+	"context"
+	"log"
+	db "simplebank/db/sqlc"
+)
+
+// IdempotencyKeyCleanupJob deletes idempotency keys past their retention
+// period. Expired keys are already ignored, this only keeps the table small.
+type IdempotencyKeyCleanupJob struct {
+	Store db.Store
+}
+
+func (g IdempotencyKeyCleanupJob) Run() {
+	count, err := g.Store.DeleteExpiredIdempotencyKeys(context.Background())
+	if err != nil {
+		log.Printf("cannot delete expired idempotency keys: %v", err)
+		return
+	}
+
+	log.Printf("deleted %d expired idempotency keys", count)
+}
@@ -0,0 +1,32 @@
+package cronjob
+
+import (
+	"context"
+	"log"
+	db "simplebank/db/sqlc"
+)
+
+// ReconciliationJob checks every customer balance against its records and
+// stores the result, optionally freezing the accounts that drifted
+type ReconciliationJob struct {
+	Store          db.Store
+	FreezeAccounts bool
+}
+
+func (g ReconciliationJob) Run() {
+	result, err := g.Store.ReconcileTx(context.Background(), db.ReconcileTxParams{
+		FreezeAccounts: g.FreezeAccounts,
+	})
+	if err != nil {
+		log.Printf("cannot reconcile balances: %v", err)
+		return
+	}
+
+	for _, drift := range result.Drifts {
+		log.Printf("account [%d] balance %d does not match records %d (%s)", drift.AccountID, drift.Balance, drift.RecordsBalance, drift.Currency)
+	}
+	for currency, sum := range db.SumDriftByCurrency(result.Drifts) {
+		log.Printf("%s drift %d", currency, sum)
+	}
+	log.Printf("reconciliation run [%d] found %d drifted accounts, froze %d", result.Run.ID, result.Run.DriftedAccounts, result.Run.FrozenAccounts)
+}
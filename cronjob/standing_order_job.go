@@ -0,0 +1,62 @@
+package cronjob
+
+import (
+	"context"
+	"log"
+	db "simplebank/db/sqlc"
+	"simplebank/db/util"
+	"time"
+)
+
+// Due orders picked up per run, the rest wait for the next one
+const standingOrderBatchSize = 100
+
+// StandingOrderJob pays the standing orders that are due. Several instances
+// may run it at once, an order is locked while it is paid.
+type StandingOrderJob struct {
+	Store                db.Store
+	FXSpread             string
+	RetryDelay           time.Duration
+	MaxAttempts          int32
+	MaxInsufficientFunds int32
+}
+
+func (g StandingOrderJob) Run() {
+	now := time.Now()
+
+	orders, err := g.Store.ListDueStandingOrders(context.Background(), db.ListDueStandingOrdersParams{
+		Now:       now,
+		MaxOrders: standingOrderBatchSize,
+	})
+	if err != nil {
+		log.Printf("cannot list due standing orders: %v", err)
+		return
+	}
+
+	for _, order := range orders {
+		result, err := g.Store.ExecuteStandingOrderTx(context.Background(), db.ExecuteStandingOrderTxParams{
+			StandingOrderID:      order.ID,
+			Now:                  now,
+			FXSpread:             g.FXSpread,
+			RetryDelay:           g.RetryDelay,
+			MaxAttempts:          g.MaxAttempts,
+			MaxInsufficientFunds: g.MaxInsufficientFunds,
+		})
+		if err != nil {
+			log.Printf("cannot execute standing order [%d]: %v", order.ID, err)
+			continue
+		}
+
+		switch result.Run.Status {
+		case "":
+			// Not due anymore, another instance got to it first
+		case util.SucceededStandingOrderRun:
+			log.Printf("standing order [%d] paid with transaction [%d]", order.ID, result.Transfer.Transaction.ID)
+		default:
+			log.Printf("standing order [%d] attempt %d %s: %s", order.ID, result.Run.Attempt, result.Run.Status, result.Run.Error)
+		}
+		if result.StandingOrder.Status == util.SuspendedStandingOrderStatus {
+			log.Printf("standing order [%d] suspended", order.ID)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+CREATE TABLE "revoked_tokens" (
+                                  "id" uuid PRIMARY KEY,
+                                  "username" varchar NOT NULL,
+                                  "expires_at" timestamptz NOT NULL,
+                                  "revoked_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "revoked_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "revoked_tokens" ("expires_at");
+
+ALTER TABLE "users" ADD COLUMN "tokens_revoked_at" timestamptz NOT NULL DEFAULT ('0001-01-01 00:00:00Z');
+
+COMMENT ON COLUMN "users"."tokens_revoked_at" IS 'Tokens issued at or before this time are rejected';
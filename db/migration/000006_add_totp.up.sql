@@ -0,0 +1,30 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar;
+
+ALTER TABLE "users" ADD COLUMN "totp_enabled" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "recovery_codes" (
+                                  "id" bigserial PRIMARY KEY,
+                                  "username" varchar NOT NULL,
+                                  "hashed_code" varchar NOT NULL,
+                                  "used_at" timestamptz,
+                                  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "mfa_challenges" (
+                                  "id" uuid PRIMARY KEY,
+                                  "username" varchar NOT NULL,
+                                  "attempts" integer NOT NULL DEFAULT 0,
+                                  "expires_at" timestamptz NOT NULL,
+                                  "used_at" timestamptz,
+                                  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "mfa_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("username", "hashed_code");
+
+CREATE INDEX ON "mfa_challenges" ("username");
+
+COMMENT ON COLUMN "users"."totp_secret" IS 'Base32 secret, only trusted once totp_enabled is true';
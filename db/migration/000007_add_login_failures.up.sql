@@ -0,0 +1,14 @@
+CREATE TABLE "login_failures" (
+                                  "kind" varchar NOT NULL,
+                                  "subject" varchar NOT NULL,
+                                  "failed_count" integer NOT NULL DEFAULT 0,
+                                  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+                                  "locked_until" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+                                  PRIMARY KEY ("kind", "subject")
+);
+
+ALTER TABLE "login_failures" ADD CONSTRAINT "login_failures_kind_check" CHECK ("kind" IN ('username', 'ip'));
+
+COMMENT ON COLUMN "login_failures"."subject" IS 'Username or client IP depending on kind';
+
+COMMENT ON COLUMN "login_failures"."locked_until" IS 'Logins for the subject are refused until this time';
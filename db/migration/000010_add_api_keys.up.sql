@@ -0,0 +1,19 @@
+CREATE TABLE "api_keys" (
+                                  "id" bigserial PRIMARY KEY,
+                                  "username" varchar NOT NULL,
+                                  "name" varchar NOT NULL,
+                                  "prefix" varchar UNIQUE NOT NULL,
+                                  "hashed_key" varchar NOT NULL,
+                                  "scopes" varchar[] NOT NULL,
+                                  "expires_at" timestamptz NOT NULL,
+                                  "revoked_at" timestamptz,
+                                  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "api_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "api_keys" ("username");
+
+COMMENT ON COLUMN "api_keys"."prefix" IS 'Public part of the key used to look it up';
+
+COMMENT ON COLUMN "api_keys"."hashed_key" IS 'SHA-256 of the full key';
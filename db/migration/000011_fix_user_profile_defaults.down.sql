@@ -0,0 +1,3 @@
+ALTER TABLE "users" ALTER COLUMN "address" SET DEFAULT (now());
+
+ALTER TABLE "users" ALTER COLUMN "contact_number" SET DEFAULT (now());
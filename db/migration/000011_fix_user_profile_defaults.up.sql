@@ -0,0 +1,3 @@
+ALTER TABLE "users" ALTER COLUMN "contact_number" DROP DEFAULT;
+
+ALTER TABLE "users" ALTER COLUMN "address" DROP DEFAULT;
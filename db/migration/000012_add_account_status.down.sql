@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "accounts_username_currency_idx";
+
+CREATE UNIQUE INDEX "accounts_username_currency_idx" ON "accounts" ("username", "currency");
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_status_check";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "status";
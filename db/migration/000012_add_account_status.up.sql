@@ -0,0 +1,10 @@
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_status_check" CHECK ("status" IN ('active', 'frozen', 'dormant', 'closed'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'Only active and dormant accounts can send or receive funds';
+
+-- A closed account must not stop its owner from opening a new one in the same currency
+DROP INDEX IF EXISTS "accounts_username_currency_idx";
+
+CREATE UNIQUE INDEX "accounts_username_currency_idx" ON "accounts" ("username", "currency") WHERE "status" <> 'closed';
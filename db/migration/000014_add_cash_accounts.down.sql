@@ -0,0 +1,9 @@
+DELETE FROM "records" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "username" = 'simplebank');
+
+DELETE FROM "transactions"
+WHERE "from_account_id" IN (SELECT "id" FROM "accounts" WHERE "username" = 'simplebank')
+   OR "to_account_id" IN (SELECT "id" FROM "accounts" WHERE "username" = 'simplebank');
+
+DELETE FROM "accounts" WHERE "username" = 'simplebank';
+
+DELETE FROM "users" WHERE "username" = 'simplebank';
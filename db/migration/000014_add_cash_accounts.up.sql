@@ -0,0 +1,11 @@
+-- The bank itself owns one cash account per currency. Deposits and withdrawals
+-- move money between it and customer accounts, so every balance is explained
+-- by records. The password hash matches nothing, nobody can log in as it.
+-- The migration fails if a customer already registered the name, rather than
+-- handing them the vault.
+INSERT INTO "users" ("username", "hashed_password", "first_name", "last_name", "email", "is_email_verified")
+VALUES ('simplebank', '!', 'Simple', 'Bank', 'cash@simplebank.internal', true);
+
+INSERT INTO "accounts" ("username", "balance", "currency", "location")
+SELECT 'simplebank', 0, "currency", 'vault'
+FROM (VALUES ('USD'), ('EUR'), ('GBP'), ('HKD'), ('CAD'), ('JPY')) AS "currencies" ("currency");
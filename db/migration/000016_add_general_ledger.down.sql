@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS "postings";
+
+DROP FUNCTION IF EXISTS "check_journal_entry_balanced"();
+
+DROP TABLE IF EXISTS "journal_entries";
+
+DELETE FROM "accounts" WHERE "username" = 'simplebank' AND "kind" IN ('fees', 'fx', 'suspense');
+
+DROP INDEX IF EXISTS "accounts_username_currency_idx";
+
+CREATE UNIQUE INDEX "accounts_username_currency_idx" ON "accounts" ("username", "currency") WHERE "status" <> 'closed';
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_kind_check";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "kind";
@@ -0,0 +1,119 @@
+-- System accounts belong to the bank, one of each kind per currency
+ALTER TABLE "accounts" ADD COLUMN "kind" varchar NOT NULL DEFAULT 'customer';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_kind_check" CHECK ("kind" IN ('customer', 'cash', 'fees', 'fx', 'suspense'));
+
+COMMENT ON COLUMN "accounts"."kind" IS 'Every kind but customer is a system account that may go negative';
+
+-- Only the vault accounts created with the bank user are cash accounts
+UPDATE "accounts" SET "kind" = 'cash' WHERE "username" = 'simplebank' AND "location" = 'vault';
+
+DROP INDEX IF EXISTS "accounts_username_currency_idx";
+
+CREATE UNIQUE INDEX "accounts_username_currency_idx" ON "accounts" ("username", "currency", "kind") WHERE "status" <> 'closed';
+
+INSERT INTO "accounts" ("username", "balance", "currency", "location", "kind")
+SELECT 'simplebank', 0, "currency", 'ledger', "kind"
+FROM (VALUES ('USD'), ('EUR'), ('GBP'), ('HKD'), ('CAD'), ('JPY')) AS "currencies" ("currency"),
+     (VALUES ('fees'), ('fx'), ('suspense')) AS "kinds" ("kind")
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE "journal_entries" (
+    "id" bigserial PRIMARY KEY,
+    "kind" varchar NOT NULL,
+    "transaction_id" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "postings" (
+    "id" bigserial PRIMARY KEY,
+    "journal_entry_id" bigint NOT NULL,
+    "account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "currency" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "journal_entries" ADD FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id");
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("journal_entry_id") REFERENCES "journal_entries" ("id");
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "postings" ADD CONSTRAINT "postings_amount_check" CHECK ("amount" <> 0);
+
+CREATE INDEX ON "journal_entries" ("transaction_id");
+
+CREATE INDEX ON "postings" ("journal_entry_id");
+
+CREATE INDEX ON "postings" ("account_id");
+
+COMMENT ON COLUMN "journal_entries"."kind" IS 'What the entry books, e.g. transfer, deposit or withdrawal';
+
+COMMENT ON COLUMN "postings"."amount" IS 'Debits are negative and credits positive, an entry sums to zero per currency';
+
+-- Checked at commit, so the postings of an entry can be inserted one by one
+CREATE FUNCTION "check_journal_entry_balanced"() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM "postings"
+        WHERE "journal_entry_id" = NEW."journal_entry_id"
+        GROUP BY "currency"
+        HAVING SUM("amount") <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % does not balance', NEW."journal_entry_id";
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "postings_balanced_trigger"
+    AFTER INSERT OR UPDATE ON "postings"
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION "check_journal_entry_balanced"();
+
+-- Balances from before the ledger are booked as opening entries against the
+-- suspense account of their currency, so every balance is a sum of postings
+DO $$
+DECLARE
+    "entry_id" bigint;
+    "opening_currency" varchar;
+BEGIN
+    FOR "opening_currency" IN
+        SELECT DISTINCT "currency" FROM "accounts" WHERE "balance" <> 0 AND "kind" <> 'suspense'
+    LOOP
+        INSERT INTO "journal_entries" ("kind") VALUES ('opening') RETURNING "id" INTO "entry_id";
+
+        INSERT INTO "postings" ("journal_entry_id", "account_id", "amount", "currency")
+        SELECT "entry_id", "id", "balance", "currency"
+        FROM "accounts"
+        WHERE "currency" = "opening_currency" AND "balance" <> 0 AND "kind" <> 'suspense';
+
+        INSERT INTO "postings" ("journal_entry_id", "account_id", "amount", "currency")
+        SELECT "entry_id", "suspense"."id", -SUM("postings"."amount"), "opening_currency"
+        FROM "postings", "accounts" AS "suspense"
+        WHERE "postings"."journal_entry_id" = "entry_id"
+          AND "suspense"."kind" = 'suspense'
+          AND "suspense"."currency" = "opening_currency"
+          AND "suspense"."status" <> 'closed'
+        GROUP BY "suspense"."id";
+
+        UPDATE "accounts" SET "balance" = "balance" + "postings"."amount"
+        FROM "postings"
+        WHERE "postings"."journal_entry_id" = "entry_id"
+          AND "postings"."account_id" = "accounts"."id"
+          AND "accounts"."kind" = 'suspense';
+    END LOOP;
+END;
+$$;
+
+-- Balances that were set without records, e.g. through the old account update,
+-- get an opening record for what the records do not explain, so that
+-- reconciliation does not take every legacy-funded account for drift
+INSERT INTO "records" ("account_id", "amount")
+SELECT "accounts"."id", "accounts"."balance" - COALESCE(SUM("records"."amount"), 0)
+FROM "accounts"
+LEFT JOIN "records" ON "records"."account_id" = "accounts"."id"
+WHERE "accounts"."kind" <> 'suspense'
+GROUP BY "accounts"."id"
+HAVING "accounts"."balance" <> COALESCE(SUM("records"."amount"), 0);
@@ -0,0 +1,27 @@
+CREATE TABLE "reconciliation_runs" (
+    "id" bigserial PRIMARY KEY,
+    "drifted_accounts" bigint NOT NULL,
+    "frozen_accounts" bigint NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "reconciliation_drifts" (
+    "id" bigserial PRIMARY KEY,
+    "run_id" bigint NOT NULL,
+    "account_id" bigint NOT NULL,
+    "currency" varchar NOT NULL,
+    "balance" bigint NOT NULL,
+    "records_balance" bigint NOT NULL,
+    "drift" bigint NOT NULL,
+    "frozen" boolean NOT NULL DEFAULT false
+);
+
+ALTER TABLE "reconciliation_drifts" ADD FOREIGN KEY ("run_id") REFERENCES "reconciliation_runs" ("id");
+
+ALTER TABLE "reconciliation_drifts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX ON "reconciliation_drifts" ("run_id");
+
+COMMENT ON COLUMN "reconciliation_drifts"."records_balance" IS 'Sum of the records of the account when the run started';
+
+COMMENT ON COLUMN "reconciliation_drifts"."drift" IS 'Balance minus records balance';
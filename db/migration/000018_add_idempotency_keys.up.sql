@@ -0,0 +1,18 @@
+CREATE TABLE "idempotency_keys" (
+    "username" varchar NOT NULL,
+    "key" varchar NOT NULL,
+    "fingerprint" varchar NOT NULL,
+    "status_code" integer,
+    "response_body" bytea,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("username", "key")
+);
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "idempotency_keys" ("expires_at");
+
+COMMENT ON COLUMN "idempotency_keys"."fingerprint" IS 'SHA-256 of the method, path and body of the first request';
+
+COMMENT ON COLUMN "idempotency_keys"."status_code" IS 'Null while the first request is still being processed';
@@ -0,0 +1,25 @@
+CREATE TABLE "exchange_rates" (
+    "id" bigserial PRIMARY KEY,
+    "from_currency" varchar NOT NULL,
+    "to_currency" varchar NOT NULL,
+    "rate" numeric(20, 10) NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "exchange_rates" ADD CONSTRAINT "exchange_rates_rate_check" CHECK ("rate" > 0);
+
+CREATE INDEX ON "exchange_rates" ("from_currency", "to_currency", "created_at");
+
+COMMENT ON COLUMN "exchange_rates"."rate" IS 'Units of to_currency for one unit of from_currency, before the spread';
+
+ALTER TABLE "transactions" ADD COLUMN "to_amount" bigint;
+
+UPDATE "transactions" SET "to_amount" = "amount";
+
+ALTER TABLE "transactions" ALTER COLUMN "to_amount" SET NOT NULL;
+
+ALTER TABLE "transactions" ADD COLUMN "exchange_rate" numeric(20, 10);
+
+COMMENT ON COLUMN "transactions"."to_amount" IS 'Amount credited in the currency of the to account';
+
+COMMENT ON COLUMN "transactions"."exchange_rate" IS 'Rate applied after the spread, null when both accounts share a currency';
@@ -0,0 +1,66 @@
+CREATE TABLE "standing_orders" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "schedule" varchar NOT NULL DEFAULT '',
+    "status" varchar NOT NULL DEFAULT 'active',
+    "occurrence_at" timestamptz NOT NULL,
+    "next_run_at" timestamptz NOT NULL,
+    "attempts" integer NOT NULL DEFAULT 0,
+    "occurrences" integer NOT NULL DEFAULT 0,
+    "insufficient_funds_failures" integer NOT NULL DEFAULT 0,
+    "max_occurrences" integer,
+    "end_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "standing_order_runs" (
+    "id" bigserial PRIMARY KEY,
+    "standing_order_id" bigint NOT NULL,
+    "occurrence_at" timestamptz NOT NULL,
+    "attempt" integer NOT NULL,
+    "status" varchar NOT NULL,
+    "transaction_id" bigint,
+    "error" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_order_runs" ADD FOREIGN KEY ("standing_order_id") REFERENCES "standing_orders" ("id");
+
+ALTER TABLE "standing_order_runs" ADD FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id");
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "standing_orders_amount_check" CHECK ("amount" > 0);
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "standing_orders_status_check"
+    CHECK ("status" IN ('active', 'suspended', 'completed', 'cancelled'));
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "standing_orders_max_occurrences_check" CHECK ("max_occurrences" > 0);
+
+ALTER TABLE "standing_order_runs" ADD CONSTRAINT "standing_order_runs_status_check"
+    CHECK ("status" IN ('succeeded', 'retrying', 'failed'));
+
+CREATE INDEX ON "standing_orders" ("username");
+
+CREATE INDEX ON "standing_orders" ("status", "next_run_at");
+
+CREATE INDEX ON "standing_order_runs" ("standing_order_id");
+
+COMMENT ON COLUMN "standing_orders"."amount" IS 'In the currency of the from account';
+
+COMMENT ON COLUMN "standing_orders"."schedule" IS 'Cron expression or RRULE, empty for a one-off order';
+
+COMMENT ON COLUMN "standing_orders"."occurrence_at" IS 'Occurrence being paid, next_run_at is later while it is retried';
+
+COMMENT ON COLUMN "standing_orders"."attempts" IS 'Failed attempts at the current occurrence';
+
+COMMENT ON COLUMN "standing_orders"."occurrences" IS 'Occurrences paid or given up on';
+
+COMMENT ON COLUMN "standing_orders"."insufficient_funds_failures" IS 'Consecutive occurrences that failed for lack of funds';
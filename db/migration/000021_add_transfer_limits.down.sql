@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "transactions_from_account_id_created_at_idx";
+
+DROP TABLE IF EXISTS "transfer_limits";
+
+ALTER TABLE IF EXISTS "users" DROP CONSTRAINT IF EXISTS "users_tier_check";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "tier";
@@ -0,0 +1,38 @@
+ALTER TABLE "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+ALTER TABLE "users" ADD CONSTRAINT "users_tier_check" CHECK ("tier" IN ('standard', 'premium', 'business'));
+
+CREATE TABLE "transfer_limits" (
+    "id" bigserial PRIMARY KEY,
+    "tier" varchar,
+    "account_id" bigint,
+    "currency" varchar NOT NULL,
+    "per_transfer" bigint,
+    "daily" bigint,
+    "monthly" bigint,
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_owner_check"
+    CHECK (("tier" IS NULL) <> ("account_id" IS NULL));
+
+ALTER TABLE "transfer_limits" ADD CONSTRAINT "transfer_limits_positive_check"
+    CHECK ("per_transfer" > 0 AND "daily" > 0 AND "monthly" > 0);
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("tier", "currency") WHERE "tier" IS NOT NULL;
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("account_id") WHERE "account_id" IS NOT NULL;
+
+CREATE INDEX ON "transactions" ("from_account_id", "created_at");
+
+COMMENT ON COLUMN "transfer_limits"."tier" IS 'Set for the limits of a user tier, null for the limits of an account';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'Set for an account, its limits override the tier of its owner';
+
+COMMENT ON COLUMN "transfer_limits"."per_transfer" IS 'Null limits do not apply';
+
+COMMENT ON COLUMN "transfer_limits"."daily" IS 'Outgoing transfers per UTC day, null limits do not apply';
+
+COMMENT ON COLUMN "transfer_limits"."monthly" IS 'Outgoing transfers per UTC month, null limits do not apply';
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "records" DROP COLUMN IF EXISTS "transaction_id";
+
+DROP INDEX IF EXISTS "transactions_reversal_of_idx";
+
+ALTER TABLE IF EXISTS "transactions" DROP COLUMN IF EXISTS "reversal_of";
@@ -0,0 +1,15 @@
+ALTER TABLE "transactions" ADD COLUMN "reversal_of" bigint;
+
+ALTER TABLE "transactions" ADD FOREIGN KEY ("reversal_of") REFERENCES "transactions" ("id");
+
+CREATE INDEX ON "transactions" ("reversal_of");
+
+ALTER TABLE "records" ADD COLUMN "transaction_id" bigint;
+
+ALTER TABLE "records" ADD FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id");
+
+CREATE INDEX ON "records" ("transaction_id");
+
+COMMENT ON COLUMN "transactions"."reversal_of" IS 'Set for a full or partial refund, the transaction it sends back';
+
+COMMENT ON COLUMN "records"."transaction_id" IS 'Null for records written before transactions were linked';
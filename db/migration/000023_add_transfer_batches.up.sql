@@ -0,0 +1,53 @@
+CREATE TABLE "transfer_batches" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "from_account_id" bigint NOT NULL,
+    "mode" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'processing',
+    "succeeded" integer NOT NULL DEFAULT 0,
+    "failed" integer NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfer_batch_lines" (
+    "id" bigserial PRIMARY KEY,
+    "batch_id" bigint NOT NULL,
+    "line" integer NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "status" varchar NOT NULL,
+    "transaction_id" bigint,
+    "error" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "transfer_batch_lines" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id");
+
+ALTER TABLE "transfer_batch_lines" ADD FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id");
+
+ALTER TABLE "transfer_batches" ADD CONSTRAINT "transfer_batches_mode_check"
+    CHECK ("mode" IN ('all_or_nothing', 'best_effort'));
+
+ALTER TABLE "transfer_batches" ADD CONSTRAINT "transfer_batches_status_check"
+    CHECK ("status" IN ('processing', 'completed', 'partially_completed', 'failed'));
+
+ALTER TABLE "transfer_batch_lines" ADD CONSTRAINT "transfer_batch_lines_status_check"
+    CHECK ("status" IN ('succeeded', 'failed', 'skipped'));
+
+CREATE INDEX ON "transfer_batches" ("username");
+
+CREATE UNIQUE INDEX ON "transfer_batch_lines" ("batch_id", "line");
+
+COMMENT ON COLUMN "transfer_batches"."mode" IS 'all_or_nothing runs every line in one transaction, best_effort runs each on its own';
+
+COMMENT ON COLUMN "transfer_batch_lines"."line" IS 'Position in the upload, starting at 1';
+
+COMMENT ON COLUMN "transfer_batch_lines"."to_account_id" IS 'As uploaded, the account may not exist';
+
+COMMENT ON COLUMN "transfer_batch_lines"."amount" IS 'In the currency of the from account of the batch';
+
+COMMENT ON COLUMN "transfer_batch_lines"."status" IS 'Skipped lines were valid but not run because the batch failed';
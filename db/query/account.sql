@@ -18,6 +18,11 @@ SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
+-- name: GetSystemAccount :one
+SELECT * FROM accounts
+WHERE kind = $1 AND currency = $2 AND status <> 'closed'
+LIMIT 1;
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
 WHERE username = $1
@@ -27,8 +32,10 @@ OFFSET $3;
 
 -- name: UpdateAccount :one
 UPDATE accounts
-SET username = $2, balance = $3, currency = $4, location = $5
-WHERE id = $1
+SET
+    location = COALESCE(sqlc.narg(location), location),
+    nickname = COALESCE(sqlc.narg(nickname), nickname)
+WHERE id = sqlc.arg(id)
 RETURNING *;
 
 -- name: AddAccountBalance :one
@@ -39,4 +46,33 @@ WHERE id = sqlc.arg(id)
 
 -- name: DeleteAccount :exec
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
+RETURNING *;
+
+-- name: CloseAccount :one
+UPDATE accounts
+SET status = 'closed'
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status) AND balance = 0
+RETURNING *;
+
+-- name: MarkDormantAccounts :execrows
+UPDATE accounts
+SET status = 'dormant'
+WHERE status = 'active'
+  AND kind = 'customer'
+  AND created_at < sqlc.arg(inactive_since)
+  AND NOT EXISTS (
+    SELECT 1 FROM records
+    WHERE records.account_id = accounts.id AND records.created_at >= sqlc.arg(inactive_since)
+);
+
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = sqlc.arg(overdraft_limit)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,26 @@
+-- name: CreateJournalEntry :one
+INSERT INTO journal_entries (
+    kind,
+    transaction_id
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: GetJournalEntry :one
+SELECT * FROM journal_entries
+WHERE id = $1 LIMIT 1;
+
+-- name: CreatePosting :one
+INSERT INTO postings (
+    journal_entry_id,
+    account_id,
+    amount,
+    currency
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListPostings :many
+SELECT * FROM postings
+WHERE journal_entry_id = $1
+ORDER BY id;
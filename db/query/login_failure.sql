@@ -0,0 +1,31 @@
+-- name: GetLoginLockedUntil :one
+SELECT COALESCE(max(locked_until), '0001-01-01 00:00:00Z')::timestamptz AS locked_until
+FROM login_failures
+WHERE (kind = 'username' AND subject = sqlc.arg(username))
+   OR (kind = 'ip' AND subject = sqlc.arg(client_ip));
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (
+    kind,
+    subject,
+    failed_count,
+    last_failed_at
+) VALUES (
+             sqlc.arg(kind), sqlc.arg(subject), 1, now()
+         )
+ON CONFLICT (kind, subject) DO UPDATE
+SET failed_count = CASE
+        WHEN login_failures.last_failed_at < sqlc.arg(window_start) THEN 1
+        ELSE login_failures.failed_count + 1
+    END,
+    last_failed_at = now()
+RETURNING *;
+
+-- name: LockLoginSubject :exec
+UPDATE login_failures
+SET locked_until = $3
+WHERE kind = $1 AND subject = $2;
+
+-- name: ClearLoginFailures :exec
+DELETE FROM login_failures
+WHERE kind = $1 AND subject = $2;
@@ -0,0 +1,31 @@
+-- name: CreateMfaChallenge :one
+INSERT INTO mfa_challenges (
+    id,
+    username,
+    expires_at
+) VALUES (
+             $1, $2, $3
+         ) RETURNING *;
+
+-- name: GetMfaChallenge :one
+SELECT * FROM mfa_challenges
+WHERE id = $1 LIMIT 1;
+
+-- name: IncrementMfaChallengeAttempts :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: UseMfaChallenge :execrows
+WITH used AS (
+    UPDATE mfa_challenges
+    SET used_at = now()
+    WHERE id = $1 AND used_at IS NULL
+    RETURNING username
+)
+UPDATE users
+SET totp_last_step = GREATEST(users.totp_last_step, $2::bigint)
+FROM used
+WHERE users.username = used.username
+  AND ($2::bigint = 0 OR users.totp_last_step < $2::bigint);
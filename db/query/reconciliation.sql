@@ -0,0 +1,45 @@
+-- name: ListBalanceDrifts :many
+SELECT accounts.id, accounts.currency, accounts.status, accounts.balance,
+       COALESCE(totals.amount, 0)::bigint AS records_balance
+FROM accounts
+LEFT JOIN (
+    SELECT account_id, SUM(amount) AS amount FROM records GROUP BY account_id
+) AS totals ON totals.account_id = accounts.id
+WHERE accounts.kind = 'customer' AND accounts.balance <> COALESCE(totals.amount, 0)
+ORDER BY accounts.id;
+
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (
+    drifted_accounts,
+    frozen_accounts
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: GetReconciliationRun :one
+SELECT * FROM reconciliation_runs
+WHERE id = $1 LIMIT 1;
+
+-- name: ListReconciliationRuns :many
+SELECT * FROM reconciliation_runs
+ORDER BY id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: CreateReconciliationDrift :one
+INSERT INTO reconciliation_drifts (
+    run_id,
+    account_id,
+    currency,
+    balance,
+    records_balance,
+    drift,
+    frozen
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ListReconciliationDrifts :many
+SELECT * FROM reconciliation_drifts
+WHERE run_id = $1
+ORDER BY id;
@@ -1,9 +1,10 @@
 -- name: CreateRecord :one
 INSERT INTO records (
     account_id,
-    amount
+    amount,
+    transaction_id
 ) VALUES (
-             $1, $2
+             $1, $2, $3
          ) RETURNING *;
 
 -- name: GetRecord :one
@@ -0,0 +1,20 @@
+-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (
+    username,
+    hashed_code
+) VALUES (
+             $1, $2
+         ) RETURNING *;
+
+-- name: GetRecoveryCode :one
+SELECT * FROM recovery_codes
+WHERE username = $1 AND hashed_code = $2 LIMIT 1;
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE username = $1;
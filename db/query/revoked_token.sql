@@ -0,0 +1,24 @@
+-- name: CreateRevokedToken :exec
+INSERT INTO revoked_tokens (
+    id,
+    username,
+    expires_at
+) VALUES (
+             $1, $2, $3
+         ) ON CONFLICT (id) DO NOTHING;
+
+-- name: GetRevokedToken :one
+SELECT * FROM revoked_tokens
+WHERE id = $1 LIMIT 1;
+
+-- name: IsTokenRevoked :one
+SELECT (
+    EXISTS (
+        SELECT 1 FROM revoked_tokens
+        WHERE revoked_tokens.id = sqlc.arg(id)
+    ) OR EXISTS (
+        SELECT 1 FROM users
+        WHERE users.username = sqlc.arg(username)
+          AND users.tokens_revoked_at >= sqlc.arg(issued_at)
+    )
+)::boolean AS revoked;
@@ -0,0 +1,82 @@
+-- name: CreateStandingOrder :one
+INSERT INTO standing_orders (
+    username,
+    from_account_id,
+    to_account_id,
+    amount,
+    schedule,
+    occurrence_at,
+    next_run_at,
+    max_occurrences,
+    end_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $6, $7, $8
+) RETURNING *;
+
+-- name: GetStandingOrder :one
+SELECT * FROM standing_orders
+WHERE id = $1 LIMIT 1;
+
+-- name: GetStandingOrderForUpdate :one
+SELECT * FROM standing_orders
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListStandingOrders :many
+SELECT * FROM standing_orders
+WHERE username = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListDueStandingOrders :many
+SELECT * FROM standing_orders
+WHERE status = 'active' AND next_run_at <= sqlc.arg(now)
+ORDER BY next_run_at
+LIMIT sqlc.arg(max_orders);
+
+-- name: UpdateStandingOrderProgress :one
+UPDATE standing_orders
+SET status = $2,
+    occurrence_at = $3,
+    next_run_at = $4,
+    attempts = $5,
+    occurrences = $6,
+    insufficient_funds_failures = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: CancelStandingOrder :one
+UPDATE standing_orders
+SET status = 'cancelled'
+WHERE id = $1 AND status IN ('active', 'suspended')
+RETURNING *;
+
+-- name: ResumeStandingOrder :one
+UPDATE standing_orders
+SET status = 'active',
+    occurrence_at = sqlc.arg(occurrence_at),
+    next_run_at = sqlc.arg(occurrence_at),
+    attempts = 0,
+    insufficient_funds_failures = 0
+WHERE id = sqlc.arg(id) AND status = 'suspended'
+RETURNING *;
+
+-- name: CreateStandingOrderRun :one
+INSERT INTO standing_order_runs (
+    standing_order_id,
+    occurrence_at,
+    attempt,
+    status,
+    transaction_id,
+    error
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListStandingOrderRuns :many
+SELECT * FROM standing_order_runs
+WHERE standing_order_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
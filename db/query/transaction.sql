@@ -2,15 +2,23 @@
 INSERT INTO transactions (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    to_amount,
+    exchange_rate,
+    reversal_of
 ) VALUES (
-             $1, $2, $3
+             $1, $2, $3, $4, $5, $6
          ) RETURNING *;
 
 -- name: GetTransaction :one
 SELECT * FROM transactions
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransactionForUpdate :one
+SELECT * FROM transactions
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListTransaction :many
 SELECT * FROM transactions
 WHERE
@@ -18,4 +26,15 @@ WHERE
         to_account_id = $2
 ORDER BY id
     LIMIT $3
-OFFSET $4;
\ No newline at end of file
+OFFSET $4;
+
+-- name: ListTransactionReversals :many
+SELECT * FROM transactions
+WHERE reversal_of = $1
+ORDER BY id;
+
+-- name: SumTransactionReversals :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS debited,
+       COALESCE(SUM(to_amount), 0)::bigint AS refunded
+FROM transactions
+WHERE reversal_of = $1;
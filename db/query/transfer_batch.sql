@@ -0,0 +1,38 @@
+-- name: CreateTransferBatch :one
+INSERT INTO transfer_batches (
+    username,
+    from_account_id,
+    mode
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetTransferBatch :one
+SELECT * FROM transfer_batches
+WHERE id = $1 LIMIT 1;
+
+-- name: FinishTransferBatch :one
+UPDATE transfer_batches
+SET status = $2,
+    succeeded = $3,
+    failed = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateTransferBatchLine :one
+INSERT INTO transfer_batch_lines (
+    batch_id,
+    line,
+    to_account_id,
+    amount,
+    status,
+    transaction_id,
+    error
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ListTransferBatchLines :many
+SELECT * FROM transfer_batch_lines
+WHERE batch_id = $1
+ORDER BY line;
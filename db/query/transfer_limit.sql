@@ -0,0 +1,65 @@
+-- name: UpsertTierTransferLimit :one
+INSERT INTO transfer_limits (
+    tier,
+    currency,
+    per_transfer,
+    daily,
+    monthly
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (tier, currency) WHERE tier IS NOT NULL DO UPDATE
+SET per_transfer = EXCLUDED.per_transfer,
+    daily = EXCLUDED.daily,
+    monthly = EXCLUDED.monthly,
+    updated_at = now()
+RETURNING *;
+
+-- name: UpsertAccountTransferLimit :one
+INSERT INTO transfer_limits (
+    account_id,
+    currency,
+    per_transfer,
+    daily,
+    monthly
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (account_id) WHERE account_id IS NOT NULL DO UPDATE
+SET per_transfer = EXCLUDED.per_transfer,
+    daily = EXCLUDED.daily,
+    monthly = EXCLUDED.monthly,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteAccountTransferLimit :execrows
+DELETE FROM transfer_limits
+WHERE account_id = $1;
+
+-- name: ListTierTransferLimits :many
+SELECT * FROM transfer_limits
+WHERE tier IS NOT NULL
+ORDER BY tier, currency;
+
+-- name: ListAccountTransferLimits :many
+SELECT transfer_limits.id, transfer_limits.tier, transfer_limits.account_id, transfer_limits.currency,
+       transfer_limits.per_transfer, transfer_limits.daily, transfer_limits.monthly, transfer_limits.updated_at
+FROM transfer_limits
+JOIN accounts ON accounts.id = sqlc.arg(account_id)
+JOIN users ON users.username = accounts.username
+WHERE transfer_limits.account_id = accounts.id
+   OR (transfer_limits.tier = users.tier AND transfer_limits.currency = accounts.currency)
+ORDER BY transfer_limits.account_id NULLS FIRST;
+
+-- name: SumOutgoingTransfers :one
+SELECT COALESCE(SUM(transactions.amount) FILTER (
+           WHERE transactions.created_at >= date_trunc('day', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+       ), 0)::bigint AS daily_total,
+       COALESCE(SUM(transactions.amount), 0)::bigint AS monthly_total
+FROM transactions
+JOIN accounts ON accounts.id = transactions.from_account_id
+JOIN journal_entries ON journal_entries.transaction_id = transactions.id
+WHERE accounts.username = sqlc.arg(username)
+  AND accounts.currency = sqlc.arg(currency)
+  AND journal_entries.kind = 'transfer'
+  AND transactions.created_at >= date_trunc('month', now() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC';
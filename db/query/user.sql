@@ -15,3 +15,61 @@ RETURNING *;
 -- name: GetUser :one
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
+
+-- name: RevokeUserTokens :exec
+UPDATE users
+SET tokens_revoked_at = now()
+WHERE username = $1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE username = $1
+RETURNING *;
+
+-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $2, totp_enabled = false, updated_at = now()
+WHERE username = $1
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled = true, totp_last_step = $2, updated_at = now()
+WHERE username = $1 AND totp_secret IS NOT NULL
+RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2, updated_at = now()
+WHERE username = $1
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true, updated_at = now()
+WHERE username = $1 AND email = $2
+RETURNING *;
+
+-- name: UpdateUser :one
+UPDATE users
+SET
+    first_name = COALESCE(sqlc.narg(first_name), first_name),
+    last_name = COALESCE(sqlc.narg(last_name), last_name),
+    email = COALESCE(sqlc.narg(email), email),
+    is_email_verified = is_email_verified AND COALESCE(sqlc.narg(email), email) = email,
+    contact_number = COALESCE(sqlc.narg(contact_number), contact_number),
+    address = COALESCE(sqlc.narg(address), address),
+    updated_at = now()
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: UpdateUserTier :one
+UPDATE users
+SET tier = $2, updated_at = now()
+WHERE username = $1
+RETURNING *;
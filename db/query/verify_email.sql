@@ -0,0 +1,18 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+    username,
+    email,
+    hashed_token,
+    expires_at
+) VALUES (
+             $1, $2, $3, $4
+         ) RETURNING *;
+
+-- name: GetVerifyEmail :one
+SELECT * FROM verify_emails
+WHERE hashed_token = $1 LIMIT 1;
+
+-- name: UseVerifyEmail :execrows
+UPDATE verify_emails
+SET used_at = now()
+WHERE id = $1 AND used_at IS NULL;
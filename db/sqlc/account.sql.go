@@ -7,13 +7,15 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-    RETURNING id, username, balance, currency, location, created_at
+    RETURNING id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind
 `
 
 type AddAccountBalanceParams struct {
@@ -31,6 +33,40 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Currency,
 		&i.Location,
 		&i.CreatedAt,
+		&i.Status,
+		&i.OverdraftLimit,
+		&i.Nickname,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const closeAccount = `-- name: CloseAccount :one
+UPDATE accounts
+SET status = 'closed'
+WHERE id = $1 AND status = $2 AND balance = 0
+RETURNING id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind
+`
+
+type CloseAccountParams struct {
+	ID         int64  `json:"id"`
+	FromStatus string `json:"from_status"`
+}
+
+func (q *Queries) CloseAccount(ctx context.Context, arg CloseAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, closeAccount, arg.ID, arg.FromStatus)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Balance,
+		&i.Currency,
+		&i.Location,
+		&i.CreatedAt,
+		&i.Status,
+		&i.OverdraftLimit,
+		&i.Nickname,
+		&i.Kind,
 	)
 	return i, err
 }
@@ -44,7 +80,7 @@ INSERT INTO accounts (
 ) VALUES (
              $1, $2, $3, $4
          )
-    RETURNING id, username, balance, currency, location, created_at
+    RETURNING id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind
 `
 
 type CreateAccountParams struct {
@@ -69,6 +105,10 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Currency,
 		&i.Location,
 		&i.CreatedAt,
+		&i.Status,
+		&i.OverdraftLimit,
+		&i.Nickname,
+		&i.Kind,
 	)
 	return i, err
 }
@@ -84,7 +124,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, username, balance, currency, location, created_at FROM accounts
+SELECT id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -98,12 +138,16 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Currency,
 		&i.Location,
 		&i.CreatedAt,
+		&i.Status,
+		&i.OverdraftLimit,
+		&i.Nickname,
+		&i.Kind,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, username, balance, currency, location, created_at FROM accounts
+SELECT id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -118,12 +162,45 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Currency,
 		&i.Location,
 		&i.CreatedAt,
+		&i.Status,
+		&i.OverdraftLimit,
+		&i.Nickname,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const getSystemAccount = `-- name: GetSystemAccount :one
+SELECT id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind FROM accounts
+WHERE kind = $1 AND currency = $2 AND status <> 'closed'
+LIMIT 1
+`
+
+type GetSystemAccountParams struct {
+	Kind     string `json:"kind"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getSystemAccount, arg.Kind, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Balance,
+		&i.Currency,
+		&i.Location,
+		&i.CreatedAt,
+		&i.Status,
+		&i.OverdraftLimit,
+		&i.Nickname,
+		&i.Kind,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, username, balance, currency, location, created_at FROM accounts
+SELECT id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind FROM accounts
 WHERE username = $1
 ORDER BY id
 LIMIT $2
@@ -152,6 +229,10 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Currency,
 			&i.Location,
 			&i.CreatedAt,
+			&i.Status,
+			&i.OverdraftLimit,
+			&i.Nickname,
+			&i.Kind,
 		); err != nil {
 			return nil, err
 		}
@@ -166,29 +247,104 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const markDormantAccounts = `-- name: MarkDormantAccounts :execrows
+UPDATE accounts
+SET status = 'dormant'
+WHERE status = 'active'
+  AND kind = 'customer'
+  AND created_at < $1
+  AND NOT EXISTS (
+    SELECT 1 FROM records
+    WHERE records.account_id = accounts.id AND records.created_at >= $1
+)
+`
+
+func (q *Queries) MarkDormantAccounts(ctx context.Context, inactiveSince time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markDormantAccounts, inactiveSince)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
-SET username = $2, balance = $3, currency = $4, location = $5
-WHERE id = $1
-RETURNING id, username, balance, currency, location, created_at
+SET
+    location = COALESCE($1, location),
+    nickname = COALESCE($2, nickname)
+WHERE id = $3
+RETURNING id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind
 `
 
 type UpdateAccountParams struct {
-	ID       int64  `json:"id"`
-	Username string `json:"username"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
-	Location string `json:"location"`
+	Location sql.NullString `json:"location"`
+	Nickname sql.NullString `json:"nickname"`
+	ID       int64          `json:"id"`
 }
 
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, updateAccount,
-		arg.ID,
-		arg.Username,
-		arg.Balance,
-		arg.Currency,
-		arg.Location,
+	row := q.db.QueryRowContext(ctx, updateAccount, arg.Location, arg.Nickname, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Balance,
+		&i.Currency,
+		&i.Location,
+		&i.CreatedAt,
+		&i.Status,
+		&i.OverdraftLimit,
+		&i.Nickname,
+		&i.Kind,
+	)
+	return i, err
+}
+
+const updateAccountOverdraftLimit = `-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $1
+WHERE id = $2
+RETURNING id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind
+`
+
+type UpdateAccountOverdraftLimitParams struct {
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	ID             int64 `json:"id"`
+}
+
+func (q *Queries) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountOverdraftLimit, arg.OverdraftLimit, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Balance,
+		&i.Currency,
+		&i.Location,
+		&i.CreatedAt,
+		&i.Status,
+		&i.OverdraftLimit,
+		&i.Nickname,
+		&i.Kind,
 	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $1
+WHERE id = $2 AND status = $3
+RETURNING id, username, balance, currency, location, created_at, status, overdraft_limit, nickname, kind
+`
+
+type UpdateAccountStatusParams struct {
+	Status     string `json:"status"`
+	ID         int64  `json:"id"`
+	FromStatus string `json:"from_status"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.Status, arg.ID, arg.FromStatus)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -197,6 +353,10 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Currency,
 		&i.Location,
 		&i.CreatedAt,
+		&i.Status,
+		&i.OverdraftLimit,
+		&i.Nickname,
+		&i.Kind,
 	)
 	return i, err
 }
@@ -57,20 +57,30 @@ func TestUpdateAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 
 	arg := UpdateAccountParams{
-		ID:      account1.ID,
-		Balance: util.RandomBalance(),
+		ID:       account1.ID,
+		Nickname: sql.NullString{String: "Savings", Valid: true},
 	}
 
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+	account2, err := testQueries.UpdateAccount(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
 	require.Equal(t, account1.ID, account2.ID)
 	require.Equal(t, account1.Username, account2.Username)
-	require.Equal(t, arg.Balance, account2.Balance)
+	require.Equal(t, account1.Balance, account2.Balance)
 	require.Equal(t, account1.Currency, account2.Currency)
 	require.Equal(t, account1.Location, account2.Location)
+	require.Equal(t, arg.Nickname.String, account2.Nickname)
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
+
+	newLocation := util.RandomLocation()
+	account3, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:       account1.ID,
+		Location: sql.NullString{String: newLocation, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newLocation, account3.Location)
+	require.Equal(t, account2.Nickname, account3.Nickname)
 }
 
 func TestDeleteAccount(t *testing.T) {
@@ -120,3 +130,71 @@ func TestGetAccountForUpdate(t *testing.T) {
 	require.Equal(t, account.Location, mockAccount.Location)
 	require.WithinDuration(t, account.CreatedAt, mockAccount.CreatedAt, time.Second)
 }
+
+func TestUpdateAccountStatus(t *testing.T) {
+	account1 := createRandomAccount(t)
+	require.Equal(t, util.ActiveAccountStatus, account1.Status)
+
+	account2, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:         account1.ID,
+		Status:     util.FrozenAccountStatus,
+		FromStatus: util.ActiveAccountStatus,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.FrozenAccountStatus, account2.Status)
+	require.Equal(t, account1.Balance, account2.Balance)
+
+	// The status has changed since it was read
+	_, err = testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:         account1.ID,
+		Status:     util.DormantAccountStatus,
+		FromStatus: util.ActiveAccountStatus,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestCloseAccount(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	arg := CloseAccountParams{
+		ID:         account1.ID,
+		FromStatus: util.ActiveAccountStatus,
+	}
+
+	// Accounts holding money cannot be closed
+	_, err := testQueries.CloseAccount(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	_, err = testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account1.ID,
+		Amount: -account1.Balance,
+	})
+	require.NoError(t, err)
+
+	account2, err := testQueries.CloseAccount(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.ClosedAccountStatus, account2.Status)
+	require.Zero(t, account2.Balance)
+
+	// A closed account frees its currency for a new account of the same user
+	account3, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Username: account1.Username,
+		Balance:  0,
+		Currency: account1.Currency,
+		Location: account1.Location,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.ActiveAccountStatus, account3.Status)
+}
+
+func TestMarkDormantAccounts(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	rows, err := testQueries.MarkDormantAccounts(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.NotZero(t, rows)
+
+	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.DormantAccountStatus, account2.Status)
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/db/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomApiKey(t *testing.T, user User) ApiKey {
+	arg := CreateApiKeyParams{
+		Username:  user.Username,
+		Name:      util.RandomString(8),
+		Prefix:    util.RandomString(8),
+		HashedKey: util.HashToken(util.RandomString(32)),
+		Scopes:    []string{util.AccountsReadScope, util.TransactionsWriteScope},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	apiKey, err := testQueries.CreateApiKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, apiKey)
+
+	require.Equal(t, arg.Username, apiKey.Username)
+	require.Equal(t, arg.Name, apiKey.Name)
+	require.Equal(t, arg.Prefix, apiKey.Prefix)
+	require.Equal(t, arg.HashedKey, apiKey.HashedKey)
+	require.Equal(t, arg.Scopes, apiKey.Scopes)
+	require.WithinDuration(t, arg.ExpiresAt, apiKey.ExpiresAt, time.Second)
+	require.False(t, apiKey.RevokedAt.Valid)
+
+	return apiKey
+}
+
+func TestGetApiKeyByPrefix(t *testing.T) {
+	user := createRandomUser(t)
+	apiKey := createRandomApiKey(t, user)
+
+	row, err := testQueries.GetApiKeyByPrefix(context.Background(), apiKey.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, apiKey.ID, row.ID)
+	require.Equal(t, apiKey.HashedKey, row.HashedKey)
+	require.Equal(t, apiKey.Scopes, row.Scopes)
+	require.Equal(t, user.Role, row.Role)
+
+	_, err = testQueries.GetApiKeyByPrefix(context.Background(), util.RandomString(8))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListApiKeys(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomApiKey(t, user)
+	}
+
+	apiKeys, err := testQueries.ListApiKeys(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, apiKeys, 3)
+	for _, apiKey := range apiKeys {
+		require.Equal(t, user.Username, apiKey.Username)
+	}
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	user := createRandomUser(t)
+	apiKey := createRandomApiKey(t, user)
+
+	// Only the owner can revoke a key
+	_, err := testQueries.RevokeApiKey(context.Background(), RevokeApiKeyParams{
+		ID:       apiKey.ID,
+		Username: createRandomUser(t).Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	arg := RevokeApiKeyParams{ID: apiKey.ID, Username: user.Username}
+	revoked, err := testQueries.RevokeApiKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	_, err = testQueries.RevokeApiKey(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
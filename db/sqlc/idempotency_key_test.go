@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/db/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := ClaimIdempotencyKeyParams{
+		Username:    user.Username,
+		Key:         util.RandomString(16),
+		Fingerprint: util.RandomString(64),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	key, err := testQueries.ClaimIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, key.Username)
+	require.Equal(t, arg.Key, key.Key)
+	require.Equal(t, arg.Fingerprint, key.Fingerprint)
+	require.False(t, key.StatusCode.Valid)
+
+	// A live key cannot be claimed twice
+	_, err = testQueries.ClaimIdempotencyKey(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	err = testQueries.SaveIdempotencyResponse(context.Background(), SaveIdempotencyResponseParams{
+		Username:     arg.Username,
+		Key:          arg.Key,
+		StatusCode:   sql.NullInt32{Int32: 200, Valid: true},
+		ResponseBody: []byte(`{"ok":true}`),
+	})
+	require.NoError(t, err)
+
+	key, err = testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: arg.Username,
+		Key:      arg.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(200), key.StatusCode.Int32)
+	require.Equal(t, []byte(`{"ok":true}`), key.ResponseBody)
+
+	// The same key belongs to each user separately
+	other := arg
+	other.Username = createRandomUser(t).Username
+	_, err = testQueries.ClaimIdempotencyKey(context.Background(), other)
+	require.NoError(t, err)
+}
+
+func TestClaimExpiredIdempotencyKey(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := ClaimIdempotencyKeyParams{
+		Username:    user.Username,
+		Key:         util.RandomString(16),
+		Fingerprint: util.RandomString(64),
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	}
+
+	_, err := testQueries.ClaimIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+
+	arg.Fingerprint = util.RandomString(64)
+	arg.ExpiresAt = time.Now().Add(time.Hour)
+	key, err := testQueries.ClaimIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Fingerprint, key.Fingerprint)
+	require.WithinDuration(t, arg.ExpiresAt, key.ExpiresAt, time.Second)
+}
+
+func TestDeleteExpiredIdempotencyKeys(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := ClaimIdempotencyKeyParams{
+		Username:    user.Username,
+		Key:         util.RandomString(16),
+		Fingerprint: util.RandomString(64),
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	}
+
+	_, err := testQueries.ClaimIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+
+	count, err := testQueries.DeleteExpiredIdempotencyKeys(context.Background())
+	require.NoError(t, err)
+	require.NotZero(t, count)
+
+	_, err = testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: arg.Username,
+		Key:      arg.Key,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"simplebank/db/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalEntryValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		postings []PostingParams
+		balanced bool
+	}{
+		{
+			name: "Balanced",
+			postings: []PostingParams{
+				{AccountID: 1, Amount: -10, Currency: util.USD},
+				{AccountID: 2, Amount: 7, Currency: util.USD},
+				{AccountID: 3, Amount: 3, Currency: util.USD},
+			},
+			balanced: true,
+		},
+		{
+			name: "Balanced Per Currency",
+			postings: []PostingParams{
+				{AccountID: 1, Amount: -10, Currency: util.USD},
+				{AccountID: 2, Amount: 10, Currency: util.USD},
+				{AccountID: 3, Amount: -8, Currency: util.EUR},
+				{AccountID: 4, Amount: 8, Currency: util.EUR},
+			},
+			balanced: true,
+		},
+		{
+			name: "Unbalanced",
+			postings: []PostingParams{
+				{AccountID: 1, Amount: -10, Currency: util.USD},
+				{AccountID: 2, Amount: 9, Currency: util.USD},
+			},
+		},
+		{
+			name: "Currencies Do Not Offset",
+			postings: []PostingParams{
+				{AccountID: 1, Amount: -10, Currency: util.USD},
+				{AccountID: 2, Amount: 10, Currency: util.EUR},
+			},
+		},
+		{
+			name: "Single Posting",
+			postings: []PostingParams{
+				{AccountID: 1, Amount: 10, Currency: util.USD},
+			},
+		},
+		{
+			name: "Zero Posting",
+			postings: []PostingParams{
+				{AccountID: 1, Amount: 0, Currency: util.USD},
+				{AccountID: 2, Amount: 0, Currency: util.USD},
+			},
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			err := JournalEntryParams{Kind: util.TransferEntryKind, Postings: testCase.postings}.validate()
+			if testCase.balanced {
+				require.NoError(t, err)
+			} else {
+				require.True(t, errors.Is(err, ErrUnbalancedEntry), err)
+			}
+		})
+	}
+}
+
+func TestTransactionTxPostsJournalEntry(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100, 0)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	result, err := store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        40,
+	})
+	require.NoError(t, err)
+
+	entry, err := testQueries.GetJournalEntry(context.Background(), result.JournalEntry.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.TransferEntryKind, entry.Kind)
+	require.Equal(t, result.Transaction.ID, entry.TransactionID.Int64)
+
+	postings, err := testQueries.ListPostings(context.Background(), entry.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, 2)
+
+	require.Equal(t, account1.ID, postings[0].AccountID)
+	require.Equal(t, int64(-40), postings[0].Amount)
+	require.Equal(t, account1.Currency, postings[0].Currency)
+	require.Equal(t, account2.ID, postings[1].AccountID)
+	require.Equal(t, int64(40), postings[1].Amount)
+
+	require.Equal(t, int64(60), result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+40, result.ToAccount.Balance)
+}
+
+func TestUnbalancedEntryRejectedOnCommit(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+
+	account := createRandomAccount(t)
+
+	// Bypasses postJournalEntry, the database must still refuse the entry
+	err := store.execTX(context.Background(), func(q *Queries) error {
+		entry, err := q.CreateJournalEntry(context.Background(), CreateJournalEntryParams{
+			Kind:          util.TransferEntryKind,
+			TransactionID: sql.NullInt64{},
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreatePosting(context.Background(), CreatePostingParams{
+			JournalEntryID: entry.ID,
+			AccountID:      account.ID,
+			Amount:         10,
+			Currency:       account.Currency,
+		})
+		return err
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not balance")
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrUnbalancedEntry is returned for journal entries whose postings do not
+// sum to zero in every currency
+var ErrUnbalancedEntry = errors.New("journal entry does not balance")
+
+type PostingParams struct {
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+}
+
+type JournalEntryParams struct {
+	Kind          string          `json:"kind"`
+	TransactionID sql.NullInt64   `json:"transaction_id"`
+	Postings      []PostingParams `json:"postings"`
+}
+
+// Checks the double-entry rule before anything is written. The database
+// checks it again when the transaction commits.
+func (arg JournalEntryParams) validate() error {
+	if len(arg.Postings) < 2 {
+		return fmt.Errorf("%w: an entry needs at least two postings", ErrUnbalancedEntry)
+	}
+
+	sums := make(map[string]int64)
+	for _, posting := range arg.Postings {
+		if posting.Amount == 0 {
+			return fmt.Errorf("%w: posting to account [%d] is zero", ErrUnbalancedEntry, posting.AccountID)
+		}
+		sums[posting.Currency] += posting.Amount
+	}
+
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: %s postings sum to %d", ErrUnbalancedEntry, currency, sum)
+		}
+	}
+	return nil
+}
+
+// Writes a journal entry with its postings and applies each posting to the
+// balance of its account. The caller must already hold locks on any customer
+// accounts, the returned accounts are in the order of the postings.
+func postJournalEntry(ctx context.Context, q *Queries, arg JournalEntryParams) (JournalEntry, []Account, error) {
+	if err := arg.validate(); err != nil {
+		return JournalEntry{}, nil, err
+	}
+
+	entry, err := q.CreateJournalEntry(ctx, CreateJournalEntryParams{
+		Kind:          arg.Kind,
+		TransactionID: arg.TransactionID,
+	})
+	if err != nil {
+		return entry, nil, err
+	}
+
+	for _, posting := range arg.Postings {
+		_, err = q.CreatePosting(ctx, CreatePostingParams{
+			JournalEntryID: entry.ID,
+			AccountID:      posting.AccountID,
+			Amount:         posting.Amount,
+			Currency:       posting.Currency,
+		})
+		if err != nil {
+			return entry, nil, err
+		}
+	}
+
+	// Balances are updated in account ID order. System accounts are shared by
+	// many entries and are only locked here, so concurrent entries touching
+	// several of them must do so in the same order.
+	order := make([]int, len(arg.Postings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return arg.Postings[order[i]].AccountID < arg.Postings[order[j]].AccountID
+	})
+
+	accounts := make([]Account, len(arg.Postings))
+	for _, i := range order {
+		accounts[i], err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.Postings[i].AccountID,
+			Amount: arg.Postings[i].Amount,
+		})
+		if err != nil {
+			return entry, nil, err
+		}
+	}
+
+	return entry, accounts, nil
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"simplebank/db/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	subject := util.RandomString(8)
+	windowStart := time.Now().Add(-time.Minute)
+
+	failure1, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Kind:        "username",
+		Subject:     subject,
+		WindowStart: windowStart,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "username", failure1.Kind)
+	require.Equal(t, subject, failure1.Subject)
+	require.Equal(t, int32(1), failure1.FailedCount)
+	require.True(t, failure1.LockedUntil.IsZero())
+
+	failure2, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Kind:        "username",
+		Subject:     subject,
+		WindowStart: windowStart,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), failure2.FailedCount)
+
+	// Failures older than the window start the count over
+	failure3, err := testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Kind:        "username",
+		Subject:     subject,
+		WindowStart: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure3.FailedCount)
+}
+
+func TestGetLoginLockedUntil(t *testing.T) {
+	username := util.RandomString(8)
+	clientIP := util.RandomString(8)
+
+	lockedUntil, err := testQueries.GetLoginLockedUntil(context.Background(), GetLoginLockedUntilParams{
+		Username: username,
+		ClientIp: clientIP,
+	})
+	require.NoError(t, err)
+	require.True(t, lockedUntil.IsZero())
+
+	_, err = testQueries.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Kind:        "ip",
+		Subject:     clientIP,
+		WindowStart: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	ipLockedUntil := time.Now().Add(time.Minute)
+	err = testQueries.LockLoginSubject(context.Background(), LockLoginSubjectParams{
+		Kind:        "ip",
+		Subject:     clientIP,
+		LockedUntil: ipLockedUntil,
+	})
+	require.NoError(t, err)
+
+	lockedUntil, err = testQueries.GetLoginLockedUntil(context.Background(), GetLoginLockedUntilParams{
+		Username: username,
+		ClientIp: clientIP,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, ipLockedUntil, lockedUntil, time.Second)
+
+	err = testQueries.ClearLoginFailures(context.Background(), ClearLoginFailuresParams{
+		Kind:    "ip",
+		Subject: clientIP,
+	})
+	require.NoError(t, err)
+
+	lockedUntil, err = testQueries.GetLoginLockedUntil(context.Background(), GetLoginLockedUntilParams{
+		Username: username,
+		ClientIp: clientIP,
+	})
+	require.NoError(t, err)
+	require.True(t, lockedUntil.IsZero())
+}
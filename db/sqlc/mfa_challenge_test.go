@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomMfaChallenge(t *testing.T, user User) MfaChallenge {
+	arg := CreateMfaChallengeParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	challenge, err := testQueries.CreateMfaChallenge(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, challenge)
+
+	require.Equal(t, arg.ID, challenge.ID)
+	require.Equal(t, arg.Username, challenge.Username)
+	require.Zero(t, challenge.Attempts)
+	require.WithinDuration(t, arg.ExpiresAt, challenge.ExpiresAt, time.Second)
+	require.False(t, challenge.UsedAt.Valid)
+	require.NotZero(t, challenge.CreatedAt)
+
+	return challenge
+}
+
+func TestCreateMfaChallenge(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomMfaChallenge(t, user)
+}
+
+func TestIncrementMfaChallengeAttempts(t *testing.T) {
+	user := createRandomUser(t)
+	challenge1 := createRandomMfaChallenge(t, user)
+
+	challenge2, err := testQueries.IncrementMfaChallengeAttempts(context.Background(), challenge1.ID)
+	require.NoError(t, err)
+	require.Equal(t, challenge1.Attempts+1, challenge2.Attempts)
+}
+
+func TestUseMfaChallenge(t *testing.T) {
+	user := createRandomUser(t)
+	challenge1 := createRandomMfaChallenge(t, user)
+
+	arg := UseMfaChallengeParams{
+		ID:       challenge1.ID,
+		TotpStep: time.Now().Unix() / 30,
+	}
+
+	rows, err := testQueries.UseMfaChallenge(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	// A challenge can only be used once
+	rows, err = testQueries.UseMfaChallenge(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	challenge2, err := testQueries.GetMfaChallenge(context.Background(), challenge1.ID)
+	require.NoError(t, err)
+	require.True(t, challenge2.UsedAt.Valid)
+
+	user2, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, arg.TotpStep, user2.TotpLastStep)
+}
+
+func TestUseMfaChallengeReplayedStep(t *testing.T) {
+	user := createRandomUser(t)
+	step := time.Now().Unix() / 30
+
+	rows, err := testQueries.UseMfaChallenge(context.Background(), UseMfaChallengeParams{
+		ID:       createRandomMfaChallenge(t, user).ID,
+		TotpStep: step,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	// The same code cannot answer another challenge
+	rows, err = testQueries.UseMfaChallenge(context.Background(), UseMfaChallengeParams{
+		ID:       createRandomMfaChallenge(t, user).ID,
+		TotpStep: step,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	// A recovery code carries no step and leaves the stored one alone
+	rows, err = testQueries.UseMfaChallenge(context.Background(), UseMfaChallengeParams{
+		ID: createRandomMfaChallenge(t, user).ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	user2, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, step, user2.TotpLastStep)
+}
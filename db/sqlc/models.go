@@ -7,6 +7,8 @@ package db
 import (
 	"database/sql"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
@@ -16,6 +18,115 @@ type Account struct {
 	Currency  string    `json:"currency"`
 	Location  string    `json:"location"`
 	CreatedAt time.Time `json:"created_at"`
+	// Only active and dormant accounts can send or receive funds
+	Status string `json:"status"`
+	// How far below zero transfers may take the balance
+	OverdraftLimit int64  `json:"overdraft_limit"`
+	Nickname       string `json:"nickname"`
+	// Every kind but customer is a system account that may go negative
+	Kind string `json:"kind"`
+}
+
+type ApiKey struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	// Public part of the key used to look it up
+	Prefix string `json:"prefix"`
+	// SHA-256 of the full key
+	HashedKey string       `json:"hashed_key"`
+	Scopes    []string     `json:"scopes"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type ExchangeRate struct {
+	ID           int64  `json:"id"`
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	// Units of to_currency for one unit of from_currency, before the spread
+	Rate      string    `json:"rate"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+	// SHA-256 of the method, path and body of the first request
+	Fingerprint string `json:"fingerprint"`
+	// Null while the first request is still being processed
+	StatusCode   sql.NullInt32 `json:"status_code"`
+	ResponseBody []byte        `json:"response_body"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type JournalEntry struct {
+	ID int64 `json:"id"`
+	// What the entry books, e.g. transfer, deposit or withdrawal
+	Kind          string        `json:"kind"`
+	TransactionID sql.NullInt64 `json:"transaction_id"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+type LoginFailure struct {
+	Kind string `json:"kind"`
+	// Username or client IP depending on kind
+	Subject      string    `json:"subject"`
+	FailedCount  int32     `json:"failed_count"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+	// Logins for the subject are refused until this time
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+type MfaChallenge struct {
+	ID        uuid.UUID    `json:"id"`
+	Username  string       `json:"username"`
+	Attempts  int32        `json:"attempts"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// SHA-256 of the token sent by email
+	HashedToken string       `json:"hashed_token"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	UsedAt      sql.NullTime `json:"used_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type Posting struct {
+	ID             int64 `json:"id"`
+	JournalEntryID int64 `json:"journal_entry_id"`
+	AccountID      int64 `json:"account_id"`
+	// Debits are negative and credits positive, an entry sums to zero per currency
+	Amount    int64     `json:"amount"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReconciliationDrift struct {
+	ID        int64  `json:"id"`
+	RunID     int64  `json:"run_id"`
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+	Balance   int64  `json:"balance"`
+	// Sum of the records of the account when the run started
+	RecordsBalance int64 `json:"records_balance"`
+	// Balance minus records balance
+	Drift  int64 `json:"drift"`
+	Frozen bool  `json:"frozen"`
+}
+
+type ReconciliationRun struct {
+	ID              int64     `json:"id"`
+	DriftedAccounts int64     `json:"drifted_accounts"`
+	FrozenAccounts  int64     `json:"frozen_accounts"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 type Record struct {
@@ -24,6 +135,69 @@ type Record struct {
 	// Can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// Null for records written before transactions were linked
+	TransactionID sql.NullInt64 `json:"transaction_id"`
+}
+
+type RecoveryCode struct {
+	ID         int64        `json:"id"`
+	Username   string       `json:"username"`
+	HashedCode string       `json:"hashed_code"`
+	UsedAt     sql.NullTime `json:"used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type RevokedToken struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type StandingOrder struct {
+	ID            int64  `json:"id"`
+	Username      string `json:"username"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// In the currency of the from account
+	Amount int64 `json:"amount"`
+	// Cron expression or RRULE, empty for a one-off order
+	Schedule string `json:"schedule"`
+	Status   string `json:"status"`
+	// Occurrence being paid, next_run_at is later while it is retried
+	OccurrenceAt time.Time `json:"occurrence_at"`
+	NextRunAt    time.Time `json:"next_run_at"`
+	// Failed attempts at the current occurrence
+	Attempts int32 `json:"attempts"`
+	// Occurrences paid or given up on
+	Occurrences int32 `json:"occurrences"`
+	// Consecutive occurrences that failed for lack of funds
+	InsufficientFundsFailures int32         `json:"insufficient_funds_failures"`
+	MaxOccurrences            sql.NullInt32 `json:"max_occurrences"`
+	EndAt                     sql.NullTime  `json:"end_at"`
+	CreatedAt                 time.Time     `json:"created_at"`
+}
+
+type StandingOrderRun struct {
+	ID              int64         `json:"id"`
+	StandingOrderID int64         `json:"standing_order_id"`
+	OccurrenceAt    time.Time     `json:"occurrence_at"`
+	Attempt         int32         `json:"attempt"`
+	Status          string        `json:"status"`
+	TransactionID   sql.NullInt64 `json:"transaction_id"`
+	Error           string        `json:"error"`
+	CreatedAt       time.Time     `json:"created_at"`
 }
 
 type Transaction struct {
@@ -33,6 +207,56 @@ type Transaction struct {
 	// Must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// Amount credited in the currency of the to account
+	ToAmount int64 `json:"to_amount"`
+	// Rate applied after the spread, null when both accounts share a currency
+	ExchangeRate sql.NullString `json:"exchange_rate"`
+	// Set for a full or partial refund, the transaction it sends back
+	ReversalOf sql.NullInt64 `json:"reversal_of"`
+}
+
+type TransferBatch struct {
+	ID            int64  `json:"id"`
+	Username      string `json:"username"`
+	FromAccountID int64  `json:"from_account_id"`
+	// all_or_nothing runs every line in one transaction, best_effort runs each on its own
+	Mode      string    `json:"mode"`
+	Status    string    `json:"status"`
+	Succeeded int32     `json:"succeeded"`
+	Failed    int32     `json:"failed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TransferBatchLine struct {
+	ID      int64 `json:"id"`
+	BatchID int64 `json:"batch_id"`
+	// Position in the upload, starting at 1
+	Line int32 `json:"line"`
+	// As uploaded, the account may not exist
+	ToAccountID int64 `json:"to_account_id"`
+	// In the currency of the from account of the batch
+	Amount int64 `json:"amount"`
+	// Skipped lines were valid but not run because the batch failed
+	Status        string        `json:"status"`
+	TransactionID sql.NullInt64 `json:"transaction_id"`
+	Error         string        `json:"error"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+type TransferLimit struct {
+	ID int64 `json:"id"`
+	// Set for the limits of a user tier, null for the limits of an account
+	Tier sql.NullString `json:"tier"`
+	// Set for an account, its limits override the tier of its owner
+	AccountID sql.NullInt64 `json:"account_id"`
+	Currency  string        `json:"currency"`
+	// Null limits do not apply
+	PerTransfer sql.NullInt64 `json:"per_transfer"`
+	// Outgoing transfers per UTC day, null limits do not apply
+	Daily sql.NullInt64 `json:"daily"`
+	// Outgoing transfers per UTC month, null limits do not apply
+	Monthly   sql.NullInt64 `json:"monthly"`
+	UpdatedAt time.Time     `json:"updated_at"`
 }
 
 type User struct {
@@ -45,4 +269,25 @@ type User struct {
 	Address        sql.NullString `json:"address"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	CreatedAt      time.Time      `json:"created_at"`
+	// Tokens issued at or before this time are rejected
+	TokensRevokedAt time.Time `json:"tokens_revoked_at"`
+	Role            string    `json:"role"`
+	// Base32 secret, only trusted once totp_enabled is true
+	TotpSecret      sql.NullString `json:"totp_secret"`
+	TotpEnabled     bool           `json:"totp_enabled"`
+	IsEmailVerified bool           `json:"is_email_verified"`
+	Tier            string         `json:"tier"`
+	// Time step of the last accepted TOTP code, codes at or before it are rejected
+	TotpLastStep int64 `json:"totp_last_step"`
+}
+
+type VerifyEmail struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// Address the token was sent to, the user must still have it when verifying
+	Email       string       `json:"email"`
+	HashedToken string       `json:"hashed_token"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	UsedAt      sql.NullTime `json:"used_at"`
+	CreatedAt   time.Time    `json:"created_at"`
 }
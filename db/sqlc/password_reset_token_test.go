@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"simplebank/db/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordResetToken(t *testing.T, user User) PasswordResetToken {
+	arg := CreatePasswordResetTokenParams{
+		Username:    user.Username,
+		HashedToken: util.HashToken(util.RandomString(32)),
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+
+	resetToken, err := testQueries.CreatePasswordResetToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, resetToken)
+
+	require.Equal(t, arg.Username, resetToken.Username)
+	require.Equal(t, arg.HashedToken, resetToken.HashedToken)
+	require.WithinDuration(t, arg.ExpiresAt, resetToken.ExpiresAt, time.Second)
+	require.False(t, resetToken.UsedAt.Valid)
+	require.NotZero(t, resetToken.CreatedAt)
+
+	return resetToken
+}
+
+func TestGetPasswordResetToken(t *testing.T) {
+	user := createRandomUser(t)
+	resetToken1 := createRandomPasswordResetToken(t, user)
+
+	resetToken2, err := testQueries.GetPasswordResetToken(context.Background(), resetToken1.HashedToken)
+	require.NoError(t, err)
+	require.Equal(t, resetToken1.ID, resetToken2.ID)
+	require.Equal(t, resetToken1.Username, resetToken2.Username)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user1 := createRandomUser(t)
+	resetToken := createRandomPasswordResetToken(t, user1)
+	otherToken := createRandomPasswordResetToken(t, user1)
+	session := createRandomSession(t, user1)
+	apiKey := createRandomApiKey(t, user1)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		TokenID:        resetToken.ID,
+		Username:       user1.Username,
+		HashedPassword: hashedPassword,
+	}
+
+	user2, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, user2.HashedPassword)
+	require.True(t, user2.UpdatedAt.After(user1.UpdatedAt))
+
+	// Every reset token of the user is spent, including ones that were not used
+	otherToken, err = testQueries.GetPasswordResetToken(context.Background(), otherToken.HashedToken)
+	require.NoError(t, err)
+	require.True(t, otherToken.UsedAt.Valid)
+
+	// The user is logged out everywhere
+	user2, err = testQueries.GetUser(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.True(t, user2.TokensRevokedAt.After(user1.TokensRevokedAt))
+	session, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+	key, err := testQueries.GetApiKeyByPrefix(context.Background(), apiKey.Prefix)
+	require.NoError(t, err)
+	require.True(t, key.RevokedAt.Valid)
+
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPasswordResetTokenUsed)
+}
+
+func TestChangePasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user1 := createRandomUser(t)
+	resetToken := createRandomPasswordResetToken(t, user1)
+	session := createRandomSession(t, user1)
+	apiKey := createRandomApiKey(t, user1)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	user2, err := store.ChangePasswordTx(context.Background(), ChangePasswordTxParams{
+		Username:       user1.Username,
+		HashedPassword: hashedPassword,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, user2.HashedPassword)
+
+	// Reset links sent before the change no longer work
+	resetToken, err = testQueries.GetPasswordResetToken(context.Background(), resetToken.HashedToken)
+	require.NoError(t, err)
+	require.True(t, resetToken.UsedAt.Valid)
+
+	// Neither do the tokens, sessions and API keys issued before it
+	user2, err = testQueries.GetUser(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.True(t, user2.TokensRevokedAt.After(user1.TokensRevokedAt))
+
+	session, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	key, err := testQueries.GetApiKeyByPrefix(context.Background(), apiKey.Prefix)
+	require.NoError(t, err)
+	require.True(t, key.RevokedAt.Valid)
+}
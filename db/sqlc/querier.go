@@ -6,24 +6,114 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	BlockSession(ctx context.Context, arg BlockSessionParams) error
+	BlockUserSessions(ctx context.Context, username string) error
+	CancelStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (IdempotencyKey, error)
+	ClearLoginFailures(ctx context.Context, arg ClearLoginFailuresParams) error
+	CloseAccount(ctx context.Context, arg CloseAccountParams) (Account, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error)
+	CreateExchangeRate(ctx context.Context, arg CreateExchangeRateParams) (ExchangeRate, error)
+	CreateJournalEntry(ctx context.Context, arg CreateJournalEntryParams) (JournalEntry, error)
+	CreateMfaChallenge(ctx context.Context, arg CreateMfaChallengeParams) (MfaChallenge, error)
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	CreatePosting(ctx context.Context, arg CreatePostingParams) (Posting, error)
+	CreateReconciliationDrift(ctx context.Context, arg CreateReconciliationDriftParams) (ReconciliationDrift, error)
+	CreateReconciliationRun(ctx context.Context, arg CreateReconciliationRunParams) (ReconciliationRun, error)
 	CreateRecord(ctx context.Context, arg CreateRecordParams) (Record, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
+	CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) error
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateStandingOrder(ctx context.Context, arg CreateStandingOrderParams) (StandingOrder, error)
+	CreateStandingOrderRun(ctx context.Context, arg CreateStandingOrderRunParams) (StandingOrderRun, error)
 	CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error)
+	CreateTransferBatch(ctx context.Context, arg CreateTransferBatchParams) (TransferBatch, error)
+	CreateTransferBatchLine(ctx context.Context, arg CreateTransferBatchLineParams) (TransferBatchLine, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountTransferLimit(ctx context.Context, accountID sql.NullInt64) (int64, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (User, error)
+	FinishTransferBatch(ctx context.Context, arg FinishTransferBatchParams) (TransferBatch, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetApiKeyByPrefix(ctx context.Context, prefix string) (GetApiKeyByPrefixRow, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetJournalEntry(ctx context.Context, id int64) (JournalEntry, error)
+	GetLatestExchangeRate(ctx context.Context, arg GetLatestExchangeRateParams) (ExchangeRate, error)
+	GetLoginLockedUntil(ctx context.Context, arg GetLoginLockedUntilParams) (time.Time, error)
+	GetMfaChallenge(ctx context.Context, id uuid.UUID) (MfaChallenge, error)
+	GetPasswordResetToken(ctx context.Context, hashedToken string) (PasswordResetToken, error)
+	GetReconciliationRun(ctx context.Context, id int64) (ReconciliationRun, error)
 	GetRecord(ctx context.Context, id int64) (Record, error)
+	GetRecoveryCode(ctx context.Context, arg GetRecoveryCodeParams) (RecoveryCode, error)
+	GetRevokedToken(ctx context.Context, id uuid.UUID) (RevokedToken, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	GetStandingOrderForUpdate(ctx context.Context, id int64) (StandingOrder, error)
+	GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (Account, error)
 	GetTransaction(ctx context.Context, id int64) (Transaction, error)
+	GetTransactionForUpdate(ctx context.Context, id int64) (Transaction, error)
+	GetTransferBatch(ctx context.Context, id int64) (TransferBatch, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetVerifyEmail(ctx context.Context, hashedToken string) (VerifyEmail, error)
+	IncrementMfaChallengeAttempts(ctx context.Context, id uuid.UUID) (MfaChallenge, error)
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	ListAccountTransferLimits(ctx context.Context, accountID int64) ([]TransferLimit, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListApiKeys(ctx context.Context, username string) ([]ApiKey, error)
+	ListBalanceDrifts(ctx context.Context) ([]ListBalanceDriftsRow, error)
+	ListDueStandingOrders(ctx context.Context, arg ListDueStandingOrdersParams) ([]StandingOrder, error)
+	ListPostings(ctx context.Context, journalEntryID int64) ([]Posting, error)
+	ListReconciliationDrifts(ctx context.Context, runID int64) ([]ReconciliationDrift, error)
+	ListReconciliationRuns(ctx context.Context, arg ListReconciliationRunsParams) ([]ReconciliationRun, error)
 	ListRecords(ctx context.Context, arg ListRecordsParams) ([]Record, error)
+	ListStandingOrderRuns(ctx context.Context, arg ListStandingOrderRunsParams) ([]StandingOrderRun, error)
+	ListStandingOrders(ctx context.Context, arg ListStandingOrdersParams) ([]StandingOrder, error)
+	ListTierTransferLimits(ctx context.Context) ([]TransferLimit, error)
 	ListTransaction(ctx context.Context, arg ListTransactionParams) ([]Transaction, error)
+	ListTransactionReversals(ctx context.Context, reversalOf sql.NullInt64) ([]Transaction, error)
+	ListTransferBatchLines(ctx context.Context, batchID int64) ([]TransferBatchLine, error)
+	LockLoginSubject(ctx context.Context, arg LockLoginSubjectParams) error
+	MarkDormantAccounts(ctx context.Context, inactiveSince time.Time) (int64, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginFailure, error)
+	ResumeStandingOrder(ctx context.Context, arg ResumeStandingOrderParams) (StandingOrder, error)
+	RevokeApiKey(ctx context.Context, arg RevokeApiKeyParams) (ApiKey, error)
+	RevokeUserApiKeys(ctx context.Context, username string) error
+	RevokeUserTokens(ctx context.Context, username string) error
+	SaveIdempotencyResponse(ctx context.Context, arg SaveIdempotencyResponseParams) error
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error)
+	SumOutgoingTransfers(ctx context.Context, arg SumOutgoingTransfersParams) (SumOutgoingTransfersRow, error)
+	SumTransactionReversals(ctx context.Context, reversalOf sql.NullInt64) (SumTransactionReversalsRow, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateStandingOrderProgress(ctx context.Context, arg UpdateStandingOrderProgressParams) (StandingOrder, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpdateUserTier(ctx context.Context, arg UpdateUserTierParams) (User, error)
+	UpsertAccountTransferLimit(ctx context.Context, arg UpsertAccountTransferLimitParams) (TransferLimit, error)
+	UpsertTierTransferLimit(ctx context.Context, arg UpsertTierTransferLimitParams) (TransferLimit, error)
+	UseMfaChallenge(ctx context.Context, arg UseMfaChallengeParams) (int64, error)
+	UsePasswordResetToken(ctx context.Context, id int64) (int64, error)
+	UseRecoveryCode(ctx context.Context, id int64) (int64, error)
+	UseUserPasswordResetTokens(ctx context.Context, username string) error
+	UseVerifyEmail(ctx context.Context, id int64) (int64, error)
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"simplebank/db/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	// The balance was set without a record, so it cannot be explained
+	driftedAccount := createFundedAccount(t, 100, 0)
+
+	cleanAccount := createFundedAccount(t, 0, 0)
+	_, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: cleanAccount.ID,
+		Amount:    50,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ReconcileTx(context.Background(), ReconcileTxParams{FreezeAccounts: true})
+	require.NoError(t, err)
+	require.NotZero(t, result.Run.ID)
+	require.Equal(t, int64(len(result.Drifts)), result.Run.DriftedAccounts)
+
+	var found bool
+	for _, drift := range result.Drifts {
+		require.NotEqual(t, cleanAccount.ID, drift.AccountID)
+		require.Equal(t, drift.Balance-drift.RecordsBalance, drift.Drift)
+
+		if drift.AccountID == driftedAccount.ID {
+			found = true
+			require.Equal(t, int64(100), drift.Balance)
+			require.Zero(t, drift.RecordsBalance)
+			require.Equal(t, int64(100), drift.Drift)
+			require.True(t, drift.Frozen)
+		}
+	}
+	require.True(t, found)
+
+	account, err := testQueries.GetAccount(context.Background(), driftedAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.FrozenAccountStatus, account.Status)
+
+	// The stored run matches what was returned
+	run, err := testQueries.GetReconciliationRun(context.Background(), result.Run.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Run.DriftedAccounts, run.DriftedAccounts)
+
+	drifts, err := testQueries.ListReconciliationDrifts(context.Background(), run.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Drifts, drifts)
+}
+
+func TestReconcileTxWithoutFreezing(t *testing.T) {
+	store := NewStore(testDB)
+
+	driftedAccount := createFundedAccount(t, 100, 0)
+
+	result, err := store.ReconcileTx(context.Background(), ReconcileTxParams{})
+	require.NoError(t, err)
+	require.Zero(t, result.Run.FrozenAccounts)
+
+	account, err := testQueries.GetAccount(context.Background(), driftedAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ActiveAccountStatus, account.Status)
+}
+
+func TestReconcileTxOpeningBalance(t *testing.T) {
+	store := NewStore(testDB)
+
+	// Funded before the ledger, the upgrade explains its balance with an
+	// opening record that belongs to no transaction
+	account := createFundedAccount(t, 100, 0)
+	_, err := testQueries.CreateRecord(context.Background(), CreateRecordParams{
+		AccountID: account.ID,
+		Amount:    100,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ReconcileTx(context.Background(), ReconcileTxParams{FreezeAccounts: true})
+	require.NoError(t, err)
+	for _, drift := range result.Drifts {
+		require.NotEqual(t, account.ID, drift.AccountID)
+	}
+
+	account, err = testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.ActiveAccountStatus, account.Status)
+}
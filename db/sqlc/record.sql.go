@@ -7,36 +7,40 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createRecord = `-- name: CreateRecord :one
 INSERT INTO records (
     account_id,
-    amount
+    amount,
+    transaction_id
 ) VALUES (
-             $1, $2
-         ) RETURNING id, account_id, amount, created_at
+             $1, $2, $3
+         ) RETURNING id, account_id, amount, created_at, transaction_id
 `
 
 type CreateRecordParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID     int64         `json:"account_id"`
+	Amount        int64         `json:"amount"`
+	TransactionID sql.NullInt64 `json:"transaction_id"`
 }
 
 func (q *Queries) CreateRecord(ctx context.Context, arg CreateRecordParams) (Record, error) {
-	row := q.db.QueryRowContext(ctx, createRecord, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createRecord, arg.AccountID, arg.Amount, arg.TransactionID)
 	var i Record
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransactionID,
 	)
 	return i, err
 }
 
 const getRecord = `-- name: GetRecord :one
-SELECT id, account_id, amount, created_at FROM records
+SELECT id, account_id, amount, created_at, transaction_id FROM records
 WHERE id = $1 LIMIT 1
 `
 
@@ -48,12 +52,13 @@ func (q *Queries) GetRecord(ctx context.Context, id int64) (Record, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransactionID,
 	)
 	return i, err
 }
 
 const listRecords = `-- name: ListRecords :many
-SELECT id, account_id, amount, created_at FROM records
+SELECT id, account_id, amount, created_at, transaction_id FROM records
 WHERE account_id = $1
 ORDER BY id
     LIMIT $2
@@ -80,6 +85,7 @@ func (q *Queries) ListRecords(ctx context.Context, arg ListRecordsParams) ([]Rec
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransactionID,
 		); err != nil {
 			return nil, err
 		}
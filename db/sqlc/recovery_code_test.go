@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/db/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRecoveryCode(t *testing.T, user User) RecoveryCode {
+	arg := CreateRecoveryCodeParams{
+		Username:   user.Username,
+		HashedCode: util.HashToken(util.RandomString(10)),
+	}
+
+	recoveryCode, err := testQueries.CreateRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, recoveryCode)
+
+	require.Equal(t, arg.Username, recoveryCode.Username)
+	require.Equal(t, arg.HashedCode, recoveryCode.HashedCode)
+	require.False(t, recoveryCode.UsedAt.Valid)
+	require.NotZero(t, recoveryCode.CreatedAt)
+
+	return recoveryCode
+}
+
+func TestGetRecoveryCode(t *testing.T) {
+	user := createRandomUser(t)
+	recoveryCode1 := createRandomRecoveryCode(t, user)
+
+	recoveryCode2, err := testQueries.GetRecoveryCode(context.Background(), GetRecoveryCodeParams{
+		Username:   user.Username,
+		HashedCode: recoveryCode1.HashedCode,
+	})
+	require.NoError(t, err)
+	require.Equal(t, recoveryCode1.ID, recoveryCode2.ID)
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	user := createRandomUser(t)
+	recoveryCode := createRandomRecoveryCode(t, user)
+
+	rows, err := testQueries.UseRecoveryCode(context.Background(), recoveryCode.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	// A recovery code can only be used once
+	rows, err = testQueries.UseRecoveryCode(context.Background(), recoveryCode.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+}
+
+func TestEnableTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+	user1 := createRandomUser(t)
+	oldCode := createRandomRecoveryCode(t, user1)
+
+	_, err := testQueries.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		Username:   user1.Username,
+		TotpSecret: sql.NullString{String: util.RandomString(32), Valid: true},
+	})
+	require.NoError(t, err)
+
+	hashedCodes := []string{util.HashToken(util.RandomString(10)), util.HashToken(util.RandomString(10))}
+	step := time.Now().Unix() / 30
+	user2, err := store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		Username:            user1.Username,
+		HashedRecoveryCodes: hashedCodes,
+		TotpStep:            step,
+	})
+	require.NoError(t, err)
+	require.True(t, user2.TotpEnabled)
+	require.Equal(t, step, user2.TotpLastStep)
+
+	// Previous recovery codes are replaced
+	_, err = testQueries.GetRecoveryCode(context.Background(), GetRecoveryCodeParams{
+		Username:   user1.Username,
+		HashedCode: oldCode.HashedCode,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	for _, hashedCode := range hashedCodes {
+		_, err = testQueries.GetRecoveryCode(context.Background(), GetRecoveryCodeParams{
+			Username:   user1.Username,
+			HashedCode: hashedCode,
+		})
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"simplebank/db/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransactionTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccountInCurrency(t, util.USD, 1000)
+	account2 := createAccountInCurrency(t, util.USD)
+
+	transfer, err := store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        400,
+	})
+	require.NoError(t, err)
+	require.Equal(t, transfer.Transaction.ID, transfer.FromRecord.TransactionID.Int64)
+	require.Equal(t, transfer.Transaction.ID, transfer.ToRecord.TransactionID.Int64)
+
+	reverse := func(amount int64) (ReverseTransactionTxResult, error) {
+		return store.ReverseTransactionTx(context.Background(), ReverseTransactionTxParams{
+			TransactionID: transfer.Transaction.ID,
+			Amount:        amount,
+		})
+	}
+
+	result, err := reverse(150)
+	require.NoError(t, err)
+	require.Equal(t, transfer.Transaction.ID, result.Original.ID)
+	require.Equal(t, int64(250), result.Refundable)
+
+	reversal := result.Reversal
+	require.Equal(t, transfer.Transaction.ID, reversal.Transaction.ReversalOf.Int64)
+	require.Equal(t, account2.ID, reversal.Transaction.FromAccountID)
+	require.Equal(t, account1.ID, reversal.Transaction.ToAccountID)
+	require.Equal(t, int64(150), reversal.Transaction.Amount)
+	require.Equal(t, int64(150), reversal.Transaction.ToAmount)
+	require.False(t, reversal.Transaction.ExchangeRate.Valid)
+	require.Equal(t, int64(-150), reversal.FromRecord.Amount)
+	require.Equal(t, int64(150), reversal.ToRecord.Amount)
+	require.Equal(t, reversal.Transaction.ID, reversal.ToRecord.TransactionID.Int64)
+	require.Equal(t, util.ReversalEntryKind, reversal.JournalEntry.Kind)
+	require.Equal(t, int64(250), reversal.FromAccount.Balance)
+	require.Equal(t, int64(750), reversal.ToAccount.Balance)
+
+	_, err = reverse(251)
+	require.True(t, errors.Is(err, ErrReversalExceedsTransaction), err)
+
+	// A reversal cannot itself be reversed
+	_, err = store.ReverseTransactionTx(context.Background(), ReverseTransactionTxParams{
+		TransactionID: reversal.Transaction.ID,
+	})
+	require.True(t, errors.Is(err, ErrTransactionNotReversible), err)
+
+	// No amount refunds the rest
+	result, err = reverse(0)
+	require.NoError(t, err)
+	require.Equal(t, int64(250), result.Reversal.Transaction.ToAmount)
+	require.Zero(t, result.Refundable)
+	require.Zero(t, result.Reversal.FromAccount.Balance)
+	require.Equal(t, int64(1000), result.Reversal.ToAccount.Balance)
+
+	_, err = reverse(0)
+	require.True(t, errors.Is(err, ErrReversalExceedsTransaction), err)
+
+	reversals, err := testQueries.ListTransactionReversals(context.Background(), sql.NullInt64{Int64: transfer.Transaction.ID, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, reversals, 2)
+	require.Equal(t, reversal.Transaction.ID, reversals[0].ID)
+}
+
+func TestReverseTransactionTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccountInCurrency(t, util.USD, 1000)
+	toAccount := createAccountInCurrency(t, util.EUR)
+
+	_, err := testQueries.CreateExchangeRate(context.Background(), CreateExchangeRateParams{
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		Rate:         "0.9",
+	})
+	require.NoError(t, err)
+
+	transfer, err := store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        1000,
+		FXSpread:      "0.01",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(891), transfer.Transaction.ToAmount)
+
+	// 891 * 333 / 1000 rounded down
+	result, err := store.ReverseTransactionTx(context.Background(), ReverseTransactionTxParams{
+		TransactionID: transfer.Transaction.ID,
+		Amount:        333,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(296), result.Reversal.Transaction.Amount)
+	require.Equal(t, int64(333), result.Reversal.Transaction.ToAmount)
+	require.Equal(t, "1.1223344557", result.Reversal.Transaction.ExchangeRate.String)
+
+	postings, err := testQueries.ListPostings(context.Background(), result.Reversal.JournalEntry.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, 4)
+
+	sums := make(map[string]int64)
+	for _, posting := range postings {
+		sums[posting.Currency] += posting.Amount
+	}
+	require.Equal(t, map[string]int64{util.USD: 0, util.EUR: 0}, sums)
+
+	// The last refund takes whatever is left of what the to account received
+	result, err = store.ReverseTransactionTx(context.Background(), ReverseTransactionTxParams{
+		TransactionID: transfer.Transaction.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(595), result.Reversal.Transaction.Amount)
+	require.Equal(t, int64(667), result.Reversal.Transaction.ToAmount)
+	require.Zero(t, result.Reversal.FromAccount.Balance)
+	require.Equal(t, int64(1000), result.Reversal.ToAccount.Balance)
+}
+
+func TestReverseTransactionTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccountInCurrency(t, util.USD, 100)
+	account2 := createAccountInCurrency(t, util.USD)
+
+	transfer, err := store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	// The to account spent what it received
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account2.ID,
+		Amount:    100,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransactionTx(context.Background(), ReverseTransactionTxParams{
+		TransactionID: transfer.Transaction.ID,
+	})
+	require.True(t, errors.Is(err, ErrInsufficientFunds), err)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRevokedToken(t *testing.T, user User) RevokedToken {
+	arg := CreateRevokedTokenParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	err := testQueries.CreateRevokedToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	revokedToken, err := testQueries.GetRevokedToken(context.Background(), arg.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, revokedToken)
+
+	require.Equal(t, arg.ID, revokedToken.ID)
+	require.Equal(t, arg.Username, revokedToken.Username)
+	require.WithinDuration(t, arg.ExpiresAt, revokedToken.ExpiresAt, time.Second)
+	require.NotZero(t, revokedToken.RevokedAt)
+
+	return revokedToken
+}
+
+func TestCreateRevokedToken(t *testing.T) {
+	user := createRandomUser(t)
+	revokedToken := createRandomRevokedToken(t, user)
+
+	// Revoking the same token twice is not an error
+	err := testQueries.CreateRevokedToken(context.Background(), CreateRevokedTokenParams{
+		ID:        revokedToken.ID,
+		Username:  revokedToken.Username,
+		ExpiresAt: revokedToken.ExpiresAt,
+	})
+	require.NoError(t, err)
+}
+
+func TestIsTokenRevoked(t *testing.T) {
+	user := createRandomUser(t)
+	revokedToken := createRandomRevokedToken(t, user)
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), IsTokenRevokedParams{
+		ID:       revokedToken.ID,
+		Username: user.Username,
+		IssuedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+		IssuedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestIsTokenRevokedAfterRevokeUserTokens(t *testing.T) {
+	user := createRandomUser(t)
+	issuedAt := time.Now()
+
+	err := testQueries.RevokeUserTokens(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+		IssuedAt: issuedAt,
+	})
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+		IssuedAt: time.Now().Add(time.Second),
+	})
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
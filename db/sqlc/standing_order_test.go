@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/db/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomStandingOrder(t *testing.T, fromAccount Account, toAccount Account, schedule string, occurrenceAt time.Time) StandingOrder {
+	arg := CreateStandingOrderParams{
+		Username:      fromAccount.Username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+		Schedule:      schedule,
+		OccurrenceAt:  occurrenceAt,
+	}
+
+	order, err := testQueries.CreateStandingOrder(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, order.ID)
+
+	require.Equal(t, arg.Username, order.Username)
+	require.Equal(t, arg.FromAccountID, order.FromAccountID)
+	require.Equal(t, arg.ToAccountID, order.ToAccountID)
+	require.Equal(t, arg.Amount, order.Amount)
+	require.Equal(t, arg.Schedule, order.Schedule)
+	require.Equal(t, util.ActiveStandingOrderStatus, order.Status)
+	require.WithinDuration(t, occurrenceAt, order.OccurrenceAt, time.Second)
+	require.WithinDuration(t, occurrenceAt, order.NextRunAt, time.Second)
+	require.Zero(t, order.Occurrences)
+
+	return order
+}
+
+func TestCreateStandingOrder(t *testing.T) {
+	createRandomStandingOrder(t, createRandomAccount(t), createRandomAccount(t), "@monthly", time.Now().Add(time.Hour))
+}
+
+func TestListDueStandingOrders(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createAccountInCurrency(t, fromAccount.Currency)
+
+	dueOrder := createRandomStandingOrder(t, fromAccount, toAccount, "", time.Now().Add(-time.Minute))
+	laterOrder := createRandomStandingOrder(t, fromAccount, toAccount, "", time.Now().Add(time.Hour))
+
+	orders, err := testQueries.ListDueStandingOrders(context.Background(), ListDueStandingOrdersParams{
+		Now:       time.Now(),
+		MaxOrders: 1000,
+	})
+	require.NoError(t, err)
+
+	ids := make(map[int64]bool)
+	for _, order := range orders {
+		require.Equal(t, util.ActiveStandingOrderStatus, order.Status)
+		ids[order.ID] = true
+	}
+	require.True(t, ids[dueOrder.ID])
+	require.False(t, ids[laterOrder.ID])
+}
+
+func TestCancelAndResumeStandingOrder(t *testing.T) {
+	order := createRandomStandingOrder(t, createRandomAccount(t), createRandomAccount(t), "@daily", time.Now().Add(time.Hour))
+
+	// Only suspended orders resume
+	_, err := testQueries.ResumeStandingOrder(context.Background(), ResumeStandingOrderParams{
+		OccurrenceAt: time.Now(),
+		ID:           order.ID,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	progress := currentStandingOrderProgress(order)
+	progress.Status = util.SuspendedStandingOrderStatus
+	progress.InsufficientFundsFailures = 3
+	_, err = testQueries.UpdateStandingOrderProgress(context.Background(), progress)
+	require.NoError(t, err)
+
+	occurrenceAt := time.Now().Add(24 * time.Hour)
+	resumed, err := testQueries.ResumeStandingOrder(context.Background(), ResumeStandingOrderParams{
+		OccurrenceAt: occurrenceAt,
+		ID:           order.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.ActiveStandingOrderStatus, resumed.Status)
+	require.Zero(t, resumed.InsufficientFundsFailures)
+	require.WithinDuration(t, occurrenceAt, resumed.NextRunAt, time.Second)
+
+	cancelled, err := testQueries.CancelStandingOrder(context.Background(), order.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.CancelledStandingOrderStatus, cancelled.Status)
+
+	_, err = testQueries.CancelStandingOrder(context.Background(), order.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func executeStandingOrder(t *testing.T, orderID int64, maxInsufficientFunds int32) ExecuteStandingOrderTxResult {
+	store := NewStore(testDB)
+
+	result, err := store.ExecuteStandingOrderTx(context.Background(), ExecuteStandingOrderTxParams{
+		StandingOrderID:      orderID,
+		Now:                  time.Now(),
+		RetryDelay:           time.Minute,
+		MaxAttempts:          3,
+		MaxInsufficientFunds: maxInsufficientFunds,
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func TestExecuteStandingOrderTx(t *testing.T) {
+	fromAccount := createFundedAccount(t, 100, 0)
+	toAccount := createAccountInCurrency(t, fromAccount.Currency)
+
+	occurrenceAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	order := createRandomStandingOrder(t, fromAccount, toAccount, "FREQ=WEEKLY", occurrenceAt)
+
+	result := executeStandingOrder(t, order.ID, 3)
+	require.Equal(t, util.SucceededStandingOrderRun, result.Run.Status)
+	require.Equal(t, int32(1), result.Run.Attempt)
+	require.Equal(t, result.Transfer.Transaction.ID, result.Run.TransactionID.Int64)
+	require.Equal(t, int64(90), result.Transfer.FromAccount.Balance)
+
+	require.Equal(t, util.ActiveStandingOrderStatus, result.StandingOrder.Status)
+	require.Equal(t, int32(1), result.StandingOrder.Occurrences)
+	require.WithinDuration(t, occurrenceAt.AddDate(0, 0, 7), result.StandingOrder.NextRunAt, time.Second)
+
+	// The next occurrence is not due yet
+	result = executeStandingOrder(t, order.ID, 3)
+	require.Empty(t, result.Run)
+	require.Equal(t, int32(1), result.StandingOrder.Occurrences)
+
+	runs, err := testQueries.ListStandingOrderRuns(context.Background(), ListStandingOrderRunsParams{
+		StandingOrderID: order.ID,
+		Limit:           10,
+		Offset:          0,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+}
+
+func TestExecuteStandingOrderTxOneOff(t *testing.T) {
+	fromAccount := createFundedAccount(t, 100, 0)
+	toAccount := createAccountInCurrency(t, fromAccount.Currency)
+
+	order := createRandomStandingOrder(t, fromAccount, toAccount, "", time.Now().Add(-time.Minute))
+
+	result := executeStandingOrder(t, order.ID, 3)
+	require.Equal(t, util.SucceededStandingOrderRun, result.Run.Status)
+	require.Equal(t, util.CompletedStandingOrderStatus, result.StandingOrder.Status)
+}
+
+func TestExecuteStandingOrderTxInsufficientFunds(t *testing.T) {
+	fromAccount := createFundedAccount(t, 5, 0)
+	toAccount := createAccountInCurrency(t, fromAccount.Currency)
+
+	// Three missed daily occurrences, all due
+	order := createRandomStandingOrder(t, fromAccount, toAccount, "FREQ=DAILY", time.Now().Add(-72*time.Hour))
+
+	result := executeStandingOrder(t, order.ID, 2)
+	require.Equal(t, util.FailedStandingOrderRun, result.Run.Status)
+	require.Contains(t, result.Run.Error, ErrInsufficientFunds.Error())
+	require.False(t, result.Run.TransactionID.Valid)
+
+	// The occurrence is skipped, not retried
+	require.Equal(t, util.ActiveStandingOrderStatus, result.StandingOrder.Status)
+	require.Equal(t, int32(1), result.StandingOrder.InsufficientFundsFailures)
+	require.Equal(t, int32(1), result.StandingOrder.Occurrences)
+	require.WithinDuration(t, order.OccurrenceAt.Add(24*time.Hour), result.StandingOrder.OccurrenceAt, time.Second)
+
+	result = executeStandingOrder(t, order.ID, 2)
+	require.Equal(t, util.FailedStandingOrderRun, result.Run.Status)
+	require.Equal(t, util.SuspendedStandingOrderStatus, result.StandingOrder.Status)
+	require.Equal(t, int32(2), result.StandingOrder.InsufficientFundsFailures)
+
+	// Suspended orders are not due
+	result = executeStandingOrder(t, order.ID, 2)
+	require.Empty(t, result.Run)
+
+	account, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), account.Balance)
+}
+
+func TestExecuteStandingOrderTxFrozenAccount(t *testing.T) {
+	fromAccount := createFundedAccount(t, 100, 0)
+	toAccount := createAccountInCurrency(t, fromAccount.Currency)
+
+	_, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:         toAccount.ID,
+		Status:     util.FrozenAccountStatus,
+		FromStatus: util.ActiveAccountStatus,
+	})
+	require.NoError(t, err)
+
+	order := createRandomStandingOrder(t, fromAccount, toAccount, "@monthly", time.Now().Add(-time.Minute))
+
+	result := executeStandingOrder(t, order.ID, 3)
+	require.Equal(t, util.FailedStandingOrderRun, result.Run.Status)
+	require.Equal(t, util.SuspendedStandingOrderStatus, result.StandingOrder.Status)
+	require.Zero(t, result.StandingOrder.InsufficientFundsFailures)
+}
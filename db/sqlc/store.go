@@ -3,12 +3,38 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"simplebank/db/util"
 )
 
+// ErrAccountCannotTransfer is wrapped with the account and its status when a
+// transfer involves a frozen or closed account
+var ErrAccountCannotTransfer = errors.New("account cannot send or receive funds")
+
+// ErrCurrencyConversion is wrapped with the reason a transfer between two
+// currencies cannot be converted
+var ErrCurrencyConversion = errors.New("cannot convert between currencies")
+
+// ErrInsufficientFunds is wrapped with the account when a transfer would take
+// its balance below its overdraft limit
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 type Store interface {
 	Querier
 	TransactionTx(ctx context.Context, arg TransactionTxParams) (TransactionTxResult, error)
+	RevokeUserTokensTx(ctx context.Context, username string) error
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (User, error)
+	ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (User, error)
+	DepositTx(ctx context.Context, arg CashTxParams) (TransactionTxResult, error)
+	WithdrawTx(ctx context.Context, arg CashTxParams) (TransactionTxResult, error)
+	ReconcileTx(ctx context.Context, arg ReconcileTxParams) (ReconcileTxResult, error)
+	ExecuteStandingOrderTx(ctx context.Context, arg ExecuteStandingOrderTxParams) (ExecuteStandingOrderTxResult, error)
+	GetTransferAllowance(ctx context.Context, account Account) (TransferAllowance, error)
+	ReverseTransactionTx(ctx context.Context, arg ReverseTransactionTxParams) (ReverseTransactionTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
 }
 
 type SQLStore struct {
@@ -46,31 +72,45 @@ func (store *SQLStore) execTX(ctx context.Context, fn func(*Queries) error) erro
 type TransactionTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	// In the currency of the from account
+	Amount int64 `json:"amount"`
+	// Fraction kept by the bank when the currencies differ, e.g. 0.005
+	FXSpread string `json:"fx_spread"`
 }
 
 type TransactionTxResult struct {
-	Transaction Transaction `json:"transaction"`
-	FromAccount Account     `json:"from_account"`
-	ToAccount   Account     `json:"to_account"`
-	FromRecord  Record      `json:"from_record"`
-	ToRecord    Record      `json:"to_record"`
+	Transaction  Transaction  `json:"transaction"`
+	JournalEntry JournalEntry `json:"journal_entry"`
+	FromAccount  Account      `json:"from_account"`
+	ToAccount    Account      `json:"to_account"`
+	FromRecord   Record       `json:"from_record"`
+	ToRecord     Record       `json:"to_record"`
 }
 
-func transfer(ctx context.Context, q *Queries, accountID1 int64, accountID2 int64, amount1 int64, amount2 int64) (account1 Account, account2 Account, err error) {
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID1,
-		Amount: amount1,
-	})
-	if err != nil {
-		return
+// Locks both accounts in ID order, so that concurrent transfers in opposite
+// directions cannot deadlock, and checks that both can move funds
+func lockTransferAccounts(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (fromAccount Account, toAccount Account, err error) {
+	firstID, secondID := fromAccountID, toAccountID
+	if firstID > secondID {
+		firstID, secondID = secondID, firstID
 	}
 
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID2,
-		Amount: amount2,
-	})
-	return
+	accounts := make(map[int64]Account, 2)
+	for _, id := range []int64{firstID, secondID} {
+		if _, ok := accounts[id]; ok {
+			continue
+		}
+		account, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return fromAccount, toAccount, err
+		}
+		if !util.CanTransferFunds(account.Status) {
+			return fromAccount, toAccount, fmt.Errorf("%w: account [%d] is %s", ErrAccountCannotTransfer, account.ID, account.Status)
+		}
+		accounts[id] = account
+	}
+
+	return accounts[fromAccountID], accounts[toAccountID], nil
 }
 
 func (store *SQLStore) TransactionTx(ctx context.Context, arg TransactionTxParams) (TransactionTxResult, error) {
@@ -78,40 +118,196 @@ func (store *SQLStore) TransactionTx(ctx context.Context, arg TransactionTxParam
 
 	err := store.execTX(ctx, func(q *Queries) error {
 		var err error
+		result, err = transferFunds(ctx, q, arg, util.TransferEntryKind)
+		return err
+	})
 
-		result.Transaction, err = q.CreateTransaction(ctx, CreateTransactionParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+	return result, err
+}
+
+// Moves money between two accounts as a journal entry of the given kind and
+// records it on both sides. Only system accounts skip the funds check, and
+// transfer limits only apply to transfers.
+func transferFunds(ctx context.Context, q *Queries, arg TransactionTxParams, kind string) (TransactionTxResult, error) {
+	var result TransactionTxResult
+
+	fromAccount, toAccount, err := lockTransferAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	if err = checkFunds(fromAccount, arg.Amount); err != nil {
+		return result, err
+	}
+
+	// Limits apply to what customers send, the from account lock keeps its own
+	// share of the totals current
+	if kind == util.TransferEntryKind && !util.IsSystemAccount(fromAccount.Kind) {
+		allowance, err := transferAllowance(ctx, q, fromAccount)
 		if err != nil {
-			return err
+			return result, err
+		}
+		if err = allowance.Check(arg.Amount); err != nil {
+			return result, err
 		}
+	}
 
-		result.FromRecord, err = q.CreateRecord(ctx, CreateRecordParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
+	// Sending money is activity by the owner, receiving it is not
+	if fromAccount.Status == util.DormantAccountStatus {
+		_, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:         fromAccount.ID,
+			Status:     util.ActiveAccountStatus,
+			FromStatus: util.DormantAccountStatus,
 		})
 		if err != nil {
-			return err
+			return result, err
+		}
+	}
+
+	conversion, err := convertTransfer(ctx, q, fromAccount, toAccount, arg)
+	if err != nil {
+		return result, err
+	}
+
+	result.Transaction, err = q.CreateTransaction(ctx, CreateTransactionParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ToAmount:      conversion.toAmount,
+		ExchangeRate:  conversion.exchangeRate,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.FromRecord, result.ToRecord, err = createTransactionRecords(ctx, q, result.Transaction)
+	if err != nil {
+		return result, err
+	}
+
+	var accounts []Account
+	result.JournalEntry, accounts, err = postJournalEntry(ctx, q, JournalEntryParams{
+		Kind:          kind,
+		TransactionID: sql.NullInt64{Int64: result.Transaction.ID, Valid: true},
+		Postings:      conversion.postings(fromAccount, toAccount, arg.Amount),
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.FromAccount, result.ToAccount = accounts[0], accounts[len(accounts)-1]
+	return result, nil
+}
+
+// The balance cannot change before commit while the row is locked. Only
+// customer accounts can run out of funds.
+func checkFunds(account Account, amount int64) error {
+	if !util.IsSystemAccount(account.Kind) && account.Balance-amount < -account.OverdraftLimit {
+		return fmt.Errorf("%w: account [%d] has %d available", ErrInsufficientFunds, account.ID, account.Balance+account.OverdraftLimit)
+	}
+	return nil
+}
+
+// Records what the transaction took from one side and gave to the other
+func createTransactionRecords(ctx context.Context, q *Queries, transaction Transaction) (fromRecord Record, toRecord Record, err error) {
+	transactionID := sql.NullInt64{Int64: transaction.ID, Valid: true}
+
+	fromRecord, err = q.CreateRecord(ctx, CreateRecordParams{
+		AccountID:     transaction.FromAccountID,
+		Amount:        -transaction.Amount,
+		TransactionID: transactionID,
+	})
+	if err != nil {
+		return fromRecord, toRecord, err
+	}
+
+	toRecord, err = q.CreateRecord(ctx, CreateRecordParams{
+		AccountID:     transaction.ToAccountID,
+		Amount:        transaction.ToAmount,
+		TransactionID: transactionID,
+	})
+	return fromRecord, toRecord, err
+}
+
+type transferConversion struct {
+	toAmount     int64
+	exchangeRate sql.NullString
+	// The FX accounts in the currencies of both sides, only set for conversions
+	fromFXAccount Account
+	toFXAccount   Account
+}
+
+// Works out what the to account receives. Between currencies the latest rate
+// less the spread is applied, and the bank's FX accounts take the other side.
+func convertTransfer(ctx context.Context, q *Queries, fromAccount Account, toAccount Account, arg TransactionTxParams) (transferConversion, error) {
+	if fromAccount.Currency == toAccount.Currency {
+		return transferConversion{toAmount: arg.Amount}, nil
+	}
+
+	var conversion transferConversion
+
+	rate, err := q.GetLatestExchangeRate(ctx, GetLatestExchangeRateParams{
+		FromCurrency: fromAccount.Currency,
+		ToCurrency:   toAccount.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return conversion, fmt.Errorf("%w: no rate from %s to %s", ErrCurrencyConversion, fromAccount.Currency, toAccount.Currency)
 		}
+		return conversion, err
+	}
+
+	toAmount, appliedRate, err := util.ConvertAmount(arg.Amount, rate.Rate, arg.FXSpread)
+	if err != nil {
+		return conversion, fmt.Errorf("%w: %v", ErrCurrencyConversion, err)
+	}
+	conversion.toAmount = toAmount
+	conversion.exchangeRate = sql.NullString{String: appliedRate, Valid: true}
 
-		result.ToRecord, err = q.CreateRecord(ctx, CreateRecordParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+	err = conversion.findFXAccounts(ctx, q, fromAccount.Currency, toAccount.Currency)
+	return conversion, err
+}
+
+// Finds the bank's FX accounts in the currencies of both sides
+func (conversion *transferConversion) findFXAccounts(ctx context.Context, q *Queries, fromCurrency string, toCurrency string) error {
+	var err error
+	for _, side := range []struct {
+		currency string
+		account  *Account
+	}{
+		{fromCurrency, &conversion.fromFXAccount},
+		{toCurrency, &conversion.toFXAccount},
+	} {
+		*side.account, err = q.GetSystemAccount(ctx, GetSystemAccountParams{
+			Kind:     util.FXAccountKind,
+			Currency: side.currency,
 		})
 		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: no FX account for %s", ErrCurrencyConversion, side.currency)
+			}
 			return err
 		}
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = transfer(ctx, q, arg.FromAccountID, arg.ToAccountID, -arg.Amount, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = transfer(ctx, q, arg.ToAccountID, arg.FromAccountID, arg.Amount, -arg.Amount)
-		}
+	return nil
+}
 
-		return nil
-	})
+// The from account always comes first and the to account last. A conversion
+// is booked as a sale to the FX account in one currency and a purchase from it
+// in the other, so each currency balances and the spread stays with the bank.
+func (conversion transferConversion) postings(fromAccount Account, toAccount Account, amount int64) []PostingParams {
+	if !conversion.exchangeRate.Valid {
+		return []PostingParams{
+			{AccountID: fromAccount.ID, Amount: -amount, Currency: fromAccount.Currency},
+			{AccountID: toAccount.ID, Amount: amount, Currency: toAccount.Currency},
+		}
+	}
 
-	return result, err
+	return []PostingParams{
+		{AccountID: fromAccount.ID, Amount: -amount, Currency: fromAccount.Currency},
+		{AccountID: conversion.fromFXAccount.ID, Amount: amount, Currency: fromAccount.Currency},
+		{AccountID: conversion.toFXAccount.ID, Amount: -conversion.toAmount, Currency: toAccount.Currency},
+		{AccountID: toAccount.ID, Amount: conversion.toAmount, Currency: toAccount.Currency},
+	}
 }
@@ -2,8 +2,10 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/require"
+	"simplebank/db/util"
 	"testing"
 )
 
@@ -11,13 +13,22 @@ func TestTransactionTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 
 	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
 	fmt.Println("Before Transaction: ", account1.Balance, account2.Balance)
 
 	// Run in goroutines
 	n := 10
 	amount := int64(10)
 
+	// Whatever order the transfers run in, none of them may be refused
+	for _, account := range []Account{account1, account2} {
+		_, err := testQueries.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+			ID:             account.ID,
+			OverdraftLimit: int64(n) * amount,
+		})
+		require.NoError(t, err)
+	}
+
 	errs := make(chan error)
 
 	for i := 0; i < n; i++ {
@@ -56,3 +67,309 @@ func TestTransactionTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updateAccount1.Balance)
 	require.Equal(t, account2.Balance, updateAccount2.Balance)
 }
+
+func TestTransactionTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	_, err := testQueries.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:         account2.ID,
+		Status:     util.FrozenAccountStatus,
+		FromStatus: util.ActiveAccountStatus,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrAccountCannotTransfer))
+
+	// Nothing moved
+	updateAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updateAccount1.Balance)
+}
+
+// Sets the balance and overdraft limit of a new USD account
+func createFundedAccount(t *testing.T, balance int64, overdraftLimit int64) Account {
+	account := createAccountInCurrency(t, util.USD)
+
+	_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: balance,
+	})
+	require.NoError(t, err)
+
+	account, err = testQueries.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account.ID,
+		OverdraftLimit: overdraftLimit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, balance, account.Balance)
+
+	return account
+}
+
+func TestTransactionTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100, 0)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	_, err := store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        101,
+	})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+
+	// The whole balance can be sent
+	result, err := store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.FromAccount.Balance)
+}
+
+func TestTransactionTxOverdraftLimitConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	balance := int64(100)
+	overdraftLimit := int64(50)
+	account1 := createFundedAccount(t, balance, overdraftLimit)
+	account2 := createAccountInCurrency(t, account1.Currency)
+
+	// Only 5 of these fit within the balance and the overdraft limit
+	n := 10
+	amount := int64(30)
+
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransactionTx(context.Background(), TransactionTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err != nil {
+			require.True(t, errors.Is(err, ErrInsufficientFunds), err)
+			continue
+		}
+		succeeded++
+	}
+	require.Equal(t, int((balance+overdraftLimit)/amount), succeeded)
+
+	updateAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, balance-int64(succeeded)*amount, updateAccount1.Balance)
+	require.GreaterOrEqual(t, updateAccount1.Balance, -overdraftLimit)
+
+	updateAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+int64(succeeded)*amount, updateAccount2.Balance)
+}
+
+func TestTransactionTxOverdraftLimitOppositeDirections(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 20, 10)
+	account2 := createFundedAccount(t, 20, 10)
+
+	n := 20
+	amount := int64(10)
+
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		fromAccountID := account1.ID
+		toAccountID := account2.ID
+		if i%2 == 1 {
+			fromAccountID = account2.ID
+			toAccountID = account1.ID
+		}
+
+		go func() {
+			_, err := store.TransactionTx(context.Background(), TransactionTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err != nil {
+			require.True(t, errors.Is(err, ErrInsufficientFunds), err)
+		}
+	}
+
+	// Money is neither created nor lost and no balance crosses its limit
+	updateAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	updateAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(40), updateAccount1.Balance+updateAccount2.Balance)
+	require.GreaterOrEqual(t, updateAccount1.Balance, -updateAccount1.OverdraftLimit)
+	require.GreaterOrEqual(t, updateAccount2.Balance, -updateAccount2.OverdraftLimit)
+}
+
+func TestDepositAndWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createFundedAccount(t, 0, 0)
+
+	cashAccount, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Kind:     util.CashAccountKind,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+
+	deposit, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, cashAccount.ID, deposit.FromAccount.ID)
+	require.Equal(t, account.ID, deposit.ToAccount.ID)
+	require.Equal(t, int64(100), deposit.ToAccount.Balance)
+	require.Equal(t, cashAccount.Balance-100, deposit.FromAccount.Balance)
+	require.Equal(t, int64(100), deposit.ToRecord.Amount)
+	require.Equal(t, int64(-100), deposit.FromRecord.Amount)
+
+	// More than the balance cannot be paid out
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    101,
+	})
+	require.True(t, errors.Is(err, ErrInsufficientFunds), err)
+
+	withdrawal, err := store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    60,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, withdrawal.FromAccount.ID)
+	require.Equal(t, cashAccount.ID, withdrawal.ToAccount.ID)
+	require.Equal(t, int64(40), withdrawal.FromAccount.Balance)
+
+	// The records explain the balance
+	records, err := testQueries.ListRecords(context.Background(), ListRecordsParams{
+		AccountID: account.ID,
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, withdrawal.FromAccount.Balance, records[0].Amount+records[1].Amount)
+}
+
+func TestDepositTxToCashAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	cashAccount, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Kind:     util.CashAccountKind,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	_, err = store.DepositTx(context.Background(), CashTxParams{
+		AccountID: cashAccount.ID,
+		Amount:    100,
+	})
+	require.True(t, errors.Is(err, ErrAccountCannotTransfer), err)
+}
+
+// Creates an empty account in the given currency
+func createAccountInCurrency(t *testing.T, currency string) Account {
+	user := createRandomUser(t)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Username: user.Username,
+		Balance:  0,
+		Currency: currency,
+		Location: util.RandomLocation(),
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestTransactionTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createAccountInCurrency(t, util.USD)
+	_, err := store.DepositTx(context.Background(), CashTxParams{AccountID: fromAccount.ID, Amount: 1000})
+	require.NoError(t, err)
+	toAccount := createAccountInCurrency(t, util.EUR)
+
+	_, err = testQueries.CreateExchangeRate(context.Background(), CreateExchangeRateParams{
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		Rate:         "0.9",
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        1000,
+		FXSpread:      "0.01",
+	})
+	require.NoError(t, err)
+
+	// 1000 * 0.9 * (1 - 0.01)
+	require.Equal(t, int64(1000), result.Transaction.Amount)
+	require.Equal(t, int64(891), result.Transaction.ToAmount)
+	require.Equal(t, "0.8910000000", result.Transaction.ExchangeRate.String)
+	require.Equal(t, int64(891), result.ToRecord.Amount)
+
+	require.Zero(t, result.FromAccount.Balance)
+	require.Equal(t, int64(891), result.ToAccount.Balance)
+
+	postings, err := testQueries.ListPostings(context.Background(), result.JournalEntry.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, 4)
+
+	sums := make(map[string]int64)
+	for _, posting := range postings {
+		sums[posting.Currency] += posting.Amount
+	}
+	require.Equal(t, map[string]int64{util.USD: 0, util.EUR: 0}, sums)
+}
+
+func TestTransactionTxNoExchangeRate(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createAccountInCurrency(t, util.USD)
+	_, err := store.DepositTx(context.Background(), CashTxParams{AccountID: fromAccount.ID, Amount: 100})
+	require.NoError(t, err)
+
+	// HKT is not a supported currency, so no rate or FX account exists for it
+	toAccount := createAccountInCurrency(t, "HKT")
+
+	_, err = store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+	})
+	require.True(t, errors.Is(err, ErrCurrencyConversion), err)
+}
@@ -7,26 +7,40 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createTransaction = `-- name: CreateTransaction :one
 INSERT INTO transactions (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    to_amount,
+    exchange_rate,
+    reversal_of
 ) VALUES (
-             $1, $2, $3
-         ) RETURNING id, from_account_id, to_account_id, amount, created_at
+             $1, $2, $3, $4, $5, $6
+         ) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of
 `
 
 type CreateTransactionParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64          `json:"from_account_id"`
+	ToAccountID   int64          `json:"to_account_id"`
+	Amount        int64          `json:"amount"`
+	ToAmount      int64          `json:"to_amount"`
+	ExchangeRate  sql.NullString `json:"exchange_rate"`
+	ReversalOf    sql.NullInt64  `json:"reversal_of"`
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
-	row := q.db.QueryRowContext(ctx, createTransaction, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransaction,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.ReversalOf,
+	)
 	var i Transaction
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +48,15 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
 const getTransaction = `-- name: GetTransaction :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transactions
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of FROM transactions
 WHERE id = $1 LIMIT 1
 `
 
@@ -52,12 +69,37 @@ func (q *Queries) GetTransaction(ctx context.Context, id int64) (Transaction, er
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+	)
+	return i, err
+}
+
+const getTransactionForUpdate = `-- name: GetTransactionForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of FROM transactions
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransactionForUpdate(ctx context.Context, id int64) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, getTransactionForUpdate, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
 const listTransaction = `-- name: ListTransaction :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transactions
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of FROM transactions
 WHERE
         from_account_id = $1 OR
         to_account_id = $2
@@ -93,6 +135,47 @@ func (q *Queries) ListTransaction(ctx context.Context, arg ListTransactionParams
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversalOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransactionReversals = `-- name: ListTransactionReversals :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of FROM transactions
+WHERE reversal_of = $1
+ORDER BY id
+`
+
+func (q *Queries) ListTransactionReversals(ctx context.Context, reversalOf sql.NullInt64) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, listTransactionReversals, reversalOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transaction{}
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversalOf,
 		); err != nil {
 			return nil, err
 		}
@@ -106,3 +189,25 @@ func (q *Queries) ListTransaction(ctx context.Context, arg ListTransactionParams
 	}
 	return items, nil
 }
+
+const sumTransactionReversals = `-- name: SumTransactionReversals :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS debited,
+       COALESCE(SUM(to_amount), 0)::bigint AS refunded
+FROM transactions
+WHERE reversal_of = $1
+`
+
+type SumTransactionReversalsRow struct {
+	Debited  int64 `json:"debited"`
+	Refunded int64 `json:"refunded"`
+}
+
+func (q *Queries) SumTransactionReversals(ctx context.Context, reversalOf sql.NullInt64) (SumTransactionReversalsRow, error) {
+	row := q.db.QueryRowContext(ctx, sumTransactionReversals, reversalOf)
+	var i SumTransactionReversalsRow
+	err := row.Scan(
+		&i.Debited,
+		&i.Refunded,
+	)
+	return i, err
+}
@@ -8,10 +8,12 @@ import (
 )
 
 func createRandomTransfer(t *testing.T, account1, account2 Account) Transaction {
+	amount := util.RandomBalance()
 	arg := CreateTransactionParams{
 		FromAccountID: account1.ID,
 		ToAccountID:   account2.ID,
-		Amount:        util.RandomBalance(),
+		Amount:        amount,
+		ToAmount:      amount,
 	}
 
 	transaction, err := testQueries.CreateTransaction(context.Background(), arg)
@@ -21,6 +23,8 @@ func createRandomTransfer(t *testing.T, account1, account2 Account) Transaction
 	require.Equal(t, arg.FromAccountID, transaction.FromAccountID)
 	require.Equal(t, arg.ToAccountID, transaction.ToAccountID)
 	require.Equal(t, arg.Amount, transaction.Amount)
+	require.Equal(t, arg.ToAmount, transaction.ToAmount)
+	require.False(t, transaction.ExchangeRate.Valid)
 
 	require.NotZero(t, transaction.ID)
 	require.NotZero(t, transaction.CreatedAt)
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"simplebank/db/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// No account gets this far in tests
+const missingAccountID = int64(1) << 40
+
+func TestBatchTransferTxAllOrNothing(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccountInCurrency(t, util.USD, 1000)
+	account1 := createAccountInCurrency(t, util.USD)
+	account2 := createAccountInCurrency(t, util.USD)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Username:      fromAccount.Username,
+		FromAccountID: fromAccount.ID,
+		Mode:          util.AllOrNothingBatchMode,
+		Lines: []BatchTransferLine{
+			{ToAccountID: account1.ID, Amount: 300},
+			{ToAccountID: account2.ID, Amount: 200},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.CompletedBatchStatus, result.Batch.Status)
+	require.Equal(t, int32(2), result.Batch.Succeeded)
+	require.Zero(t, result.Batch.Failed)
+	require.Len(t, result.Lines, 2)
+	for i, line := range result.Lines {
+		require.Equal(t, int32(i+1), line.Line)
+		require.Equal(t, util.SucceededBatchLine, line.Status)
+		require.True(t, line.TransactionID.Valid)
+	}
+
+	account, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(500), account.Balance)
+
+	// One bad line and nothing moves
+	result, err = store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Username:      fromAccount.Username,
+		FromAccountID: fromAccount.ID,
+		Mode:          util.AllOrNothingBatchMode,
+		Lines: []BatchTransferLine{
+			{ToAccountID: account1.ID, Amount: 100},
+			{ToAccountID: missingAccountID, Amount: 100},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.FailedBatchStatus, result.Batch.Status)
+	require.Equal(t, int32(1), result.Batch.Failed)
+	require.Equal(t, util.SkippedBatchLine, result.Lines[0].Status)
+	require.Equal(t, util.FailedBatchLine, result.Lines[1].Status)
+	require.Contains(t, result.Lines[1].Error, ErrAccountNotFound.Error())
+
+	account, err = testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(500), account.Balance)
+
+	lines, err := testQueries.ListTransferBatchLines(context.Background(), result.Batch.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Lines, lines)
+}
+
+func TestBatchTransferTxAllOrNothingConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	accounts := []Account{
+		createFundedAccountInCurrency(t, util.USD, 1000),
+		createFundedAccountInCurrency(t, util.USD, 1000),
+		createFundedAccountInCurrency(t, util.USD, 1000),
+	}
+
+	// Every batch pays the other two accounts, highest ID first, so that
+	// locking pair by pair would deadlock
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		for j := range accounts {
+			fromAccount := accounts[j]
+			lines := []BatchTransferLine{
+				{ToAccountID: accounts[(j+2)%3].ID, Amount: 10},
+				{ToAccountID: accounts[(j+1)%3].ID, Amount: 10},
+			}
+			if lines[0].ToAccountID < lines[1].ToAccountID {
+				lines[0], lines[1] = lines[1], lines[0]
+			}
+
+			go func() {
+				result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+					Username:      fromAccount.Username,
+					FromAccountID: fromAccount.ID,
+					Mode:          util.AllOrNothingBatchMode,
+					Lines:         lines,
+				})
+				if err == nil && result.Batch.Status != util.CompletedBatchStatus {
+					err = fmt.Errorf("batch %d is %s", result.Batch.ID, result.Batch.Status)
+				}
+				errs <- err
+			}()
+		}
+	}
+
+	for i := 0; i < n*len(accounts); i++ {
+		require.NoError(t, <-errs)
+	}
+
+	// Each account sent and received the same amount
+	for _, account := range accounts {
+		updated, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1000), updated.Balance)
+	}
+}
+
+func TestBatchTransferTxBestEffort(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccountInCurrency(t, util.USD, 500)
+	account1 := createAccountInCurrency(t, util.USD)
+	account2 := createAccountInCurrency(t, util.USD)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		Username:      fromAccount.Username,
+		FromAccountID: fromAccount.ID,
+		Mode:          util.BestEffortBatchMode,
+		Lines: []BatchTransferLine{
+			{ToAccountID: account1.ID, Amount: 300},
+			// The line before leaves 200
+			{ToAccountID: account2.ID, Amount: 300},
+			{ToAccountID: missingAccountID, Amount: 100},
+			{ToAccountID: fromAccount.ID, Amount: 100},
+			{ToAccountID: account2.ID, Amount: 200},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.PartiallyCompletedBatchStatus, result.Batch.Status)
+	require.Equal(t, int32(2), result.Batch.Succeeded)
+	require.Equal(t, int32(3), result.Batch.Failed)
+
+	statuses := make([]string, len(result.Lines))
+	for i, line := range result.Lines {
+		statuses[i] = line.Status
+	}
+	require.Equal(t, []string{
+		util.SucceededBatchLine,
+		util.FailedBatchLine,
+		util.FailedBatchLine,
+		util.FailedBatchLine,
+		util.SucceededBatchLine,
+	}, statuses)
+	require.Contains(t, result.Lines[1].Error, ErrInsufficientFunds.Error())
+
+	account, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.Balance)
+
+	batch, err := testQueries.GetTransferBatch(context.Background(), result.Batch.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Batch, batch)
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTransferLimitExceeded is wrapped by TransferLimitError
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+const (
+	// Kinds of transfer limits
+	PerTransferLimit = "per_transfer"
+	DailyLimit       = "daily"
+	MonthlyLimit     = "monthly"
+)
+
+// TransferLimitError reports the limit a transfer would break and how much
+// the account can still send under it
+type TransferLimitError struct {
+	AccountID int64
+	Limit     string
+	Currency  string
+	Max       int64
+	Remaining int64
+}
+
+func (err *TransferLimitError) Error() string {
+	return fmt.Sprintf("%v: account [%d] has a %s limit of %d %s, %d remaining",
+		ErrTransferLimitExceeded, err.AccountID, err.Limit, err.Max, err.Currency, err.Remaining)
+}
+
+func (err *TransferLimitError) Unwrap() error {
+	return ErrTransferLimitExceeded
+}
+
+// TransferAllowance holds the limits of an account and what is left of them
+// for the current UTC day and month. Nil limits do not apply.
+type TransferAllowance struct {
+	AccountID        int64  `json:"account_id"`
+	Currency         string `json:"currency"`
+	PerTransfer      *int64 `json:"per_transfer,omitempty"`
+	Daily            *int64 `json:"daily,omitempty"`
+	Monthly          *int64 `json:"monthly,omitempty"`
+	DailyRemaining   *int64 `json:"daily_remaining,omitempty"`
+	MonthlyRemaining *int64 `json:"monthly_remaining,omitempty"`
+}
+
+// Returns a TransferLimitError when sending the amount breaks a limit
+func (allowance TransferAllowance) Check(amount int64) error {
+	for _, limit := range []struct {
+		kind      string
+		max       *int64
+		remaining *int64
+	}{
+		{PerTransferLimit, allowance.PerTransfer, allowance.PerTransfer},
+		{DailyLimit, allowance.Daily, allowance.DailyRemaining},
+		{MonthlyLimit, allowance.Monthly, allowance.MonthlyRemaining},
+	} {
+		if limit.max != nil && amount > *limit.remaining {
+			return &TransferLimitError{
+				AccountID: allowance.AccountID,
+				Limit:     limit.kind,
+				Currency:  allowance.Currency,
+				Max:       *limit.max,
+				Remaining: *limit.remaining,
+			}
+		}
+	}
+	return nil
+}
+
+func (store *SQLStore) GetTransferAllowance(ctx context.Context, account Account) (TransferAllowance, error) {
+	return transferAllowance(ctx, store.Queries, account)
+}
+
+// Works out the limits of the account from the tier of its owner and its own
+// limits, which take precedence where set, and subtracts the transfers the
+// owner sent this day and month from all of their accounts in its currency,
+// so that opening another account does not reset the limits.
+func transferAllowance(ctx context.Context, q *Queries, account Account) (TransferAllowance, error) {
+	allowance := TransferAllowance{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+	}
+
+	// The tier limits come first
+	limits, err := q.ListAccountTransferLimits(ctx, account.ID)
+	if err != nil {
+		return allowance, err
+	}
+	for _, limit := range limits {
+		if limit.PerTransfer.Valid {
+			allowance.PerTransfer = &limit.PerTransfer.Int64
+		}
+		if limit.Daily.Valid {
+			allowance.Daily = &limit.Daily.Int64
+		}
+		if limit.Monthly.Valid {
+			allowance.Monthly = &limit.Monthly.Int64
+		}
+	}
+
+	if allowance.Daily == nil && allowance.Monthly == nil {
+		return allowance, nil
+	}
+
+	totals, err := q.SumOutgoingTransfers(ctx, SumOutgoingTransfersParams{
+		Username: account.Username,
+		Currency: account.Currency,
+	})
+	if err != nil {
+		return allowance, err
+	}
+	if allowance.Daily != nil {
+		allowance.DailyRemaining = remainingAllowance(*allowance.Daily, totals.DailyTotal)
+	}
+	if allowance.Monthly != nil {
+		allowance.MonthlyRemaining = remainingAllowance(*allowance.Monthly, totals.MonthlyTotal)
+	}
+
+	return allowance, nil
+}
+
+// Limits may be lowered below what was already sent
+func remainingAllowance(limit int64, total int64) *int64 {
+	remaining := limit - total
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
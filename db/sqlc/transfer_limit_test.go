@@ -0,0 +1,204 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"simplebank/db/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createFundedAccountInCurrency(t *testing.T, currency string, balance int64) Account {
+	account := createAccountInCurrency(t, currency)
+
+	_, err := NewStore(testDB).DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    balance,
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func requireTransferLimitError(t *testing.T, err error, limit string, remaining int64) {
+	require.True(t, errors.Is(err, ErrTransferLimitExceeded), err)
+
+	var limitErr *TransferLimitError
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, limit, limitErr.Limit)
+	require.Equal(t, remaining, limitErr.Remaining)
+}
+
+func TestTransactionTxAccountTransferLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccountInCurrency(t, util.USD, 1000)
+	account2 := createAccountInCurrency(t, util.USD)
+
+	_, err := testQueries.UpsertAccountTransferLimit(context.Background(), UpsertAccountTransferLimitParams{
+		AccountID:   sql.NullInt64{Int64: account1.ID, Valid: true},
+		Currency:    account1.Currency,
+		PerTransfer: sql.NullInt64{Int64: 50, Valid: true},
+		Daily:       sql.NullInt64{Int64: 100, Valid: true},
+	})
+	require.NoError(t, err)
+
+	transfer := func(amount int64) error {
+		_, err := store.TransactionTx(context.Background(), TransactionTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		return err
+	}
+
+	requireTransferLimitError(t, transfer(60), PerTransferLimit, 50)
+	require.NoError(t, transfer(50))
+	require.NoError(t, transfer(40))
+	requireTransferLimitError(t, transfer(20), DailyLimit, 10)
+
+	allowance, err := store.GetTransferAllowance(context.Background(), account1)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), *allowance.DailyRemaining)
+	require.Nil(t, allowance.Monthly)
+	require.Nil(t, allowance.MonthlyRemaining)
+
+	// Back on the tier limits, which the standard tier does not have in tests
+	count, err := testQueries.DeleteAccountTransferLimit(context.Background(), sql.NullInt64{Int64: account1.ID, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	require.NoError(t, transfer(20))
+}
+
+func TestTransactionTxTierTransferLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccountInCurrency(t, util.USD, 1000)
+	account2 := createAccountInCurrency(t, util.USD)
+
+	_, err := testQueries.UpdateUserTier(context.Background(), UpdateUserTierParams{
+		Username: account1.Username,
+		Tier:     util.BusinessTier,
+	})
+	require.NoError(t, err)
+
+	tierLimit := UpsertTierTransferLimitParams{
+		Tier:     sql.NullString{String: util.BusinessTier, Valid: true},
+		Currency: util.USD,
+		Monthly:  sql.NullInt64{Int64: 30, Valid: true},
+	}
+	limit, err := testQueries.UpsertTierTransferLimit(context.Background(), tierLimit)
+	require.NoError(t, err)
+	require.Equal(t, int64(30), limit.Monthly.Int64)
+	// Other tests expect tiers without limits
+	t.Cleanup(func() {
+		_, err := testQueries.UpsertTierTransferLimit(context.Background(), UpsertTierTransferLimitParams{
+			Tier:     tierLimit.Tier,
+			Currency: tierLimit.Currency,
+		})
+		require.NoError(t, err)
+	})
+
+	_, err = store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        31,
+	})
+	requireTransferLimitError(t, err, MonthlyLimit, 30)
+
+	// The account limit takes precedence over the tier
+	_, err = testQueries.UpsertAccountTransferLimit(context.Background(), UpsertAccountTransferLimitParams{
+		AccountID: sql.NullInt64{Int64: account1.ID, Valid: true},
+		Currency:  account1.Currency,
+		Monthly:   sql.NullInt64{Int64: 100, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        31,
+	})
+	require.NoError(t, err)
+
+	// Limits only apply to transfers, not to cash withdrawals
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account1.ID,
+		Amount:    500,
+	})
+	require.NoError(t, err)
+
+	limits, err := testQueries.ListTierTransferLimits(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, limits)
+}
+
+func TestTransactionTxTransferLimitsAcrossAccounts(t *testing.T) {
+	store := NewStore(testDB)
+
+	oldAccount := createFundedAccountInCurrency(t, util.USD, 100)
+	toAccount := createAccountInCurrency(t, util.USD)
+
+	_, err := testQueries.UpdateUserTier(context.Background(), UpdateUserTierParams{
+		Username: oldAccount.Username,
+		Tier:     util.PremiumTier,
+	})
+	require.NoError(t, err)
+
+	tierLimit := UpsertTierTransferLimitParams{
+		Tier:     sql.NullString{String: util.PremiumTier, Valid: true},
+		Currency: util.USD,
+		Daily:    sql.NullInt64{Int64: 100, Valid: true},
+	}
+	_, err = testQueries.UpsertTierTransferLimit(context.Background(), tierLimit)
+	require.NoError(t, err)
+	// Other tests expect tiers without limits
+	t.Cleanup(func() {
+		_, err := testQueries.UpsertTierTransferLimit(context.Background(), UpsertTierTransferLimitParams{
+			Tier:     tierLimit.Tier,
+			Currency: tierLimit.Currency,
+		})
+		require.NoError(t, err)
+	})
+
+	_, err = store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: oldAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        80,
+	})
+	require.NoError(t, err)
+
+	// Closing the account and opening another does not reset the limit
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: oldAccount.ID,
+		Amount:    20,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CloseAccount(context.Background(), CloseAccountParams{
+		ID:         oldAccount.ID,
+		FromStatus: util.ActiveAccountStatus,
+	})
+	require.NoError(t, err)
+
+	newAccount, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Username: oldAccount.Username,
+		Balance:  0,
+		Currency: util.USD,
+		Location: oldAccount.Location,
+	})
+	require.NoError(t, err)
+	_, err = store.DepositTx(context.Background(), CashTxParams{
+		AccountID: newAccount.ID,
+		Amount:    100,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransactionTx(context.Background(), TransactionTxParams{
+		FromAccountID: newAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        30,
+	})
+	requireTransferLimitError(t, err, DailyLimit, 20)
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"simplebank/db/util"
+)
+
+// ErrNoCashAccount is returned when the bank has no open cash account in the
+// currency of the customer account
+var ErrNoCashAccount = errors.New("no cash account for currency")
+
+type CashTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// Books cash paid in at a teller as a transfer from the cash account of the
+// same currency, which as a system account is allowed to go negative
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashTxParams) (TransactionTxResult, error) {
+	var result TransactionTxResult
+
+	err := store.execTX(ctx, func(q *Queries) error {
+		cashAccount, err := getCashAccount(ctx, q, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		result, err = transferFunds(ctx, q, TransactionTxParams{
+			FromAccountID: cashAccount.ID,
+			ToAccountID:   arg.AccountID,
+			Amount:        arg.Amount,
+		}, util.DepositEntryKind)
+		return err
+	})
+
+	return result, err
+}
+
+// Books cash paid out at a teller as a transfer to the cash account of the
+// same currency, subject to the same funds check as any other transfer
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashTxParams) (TransactionTxResult, error) {
+	var result TransactionTxResult
+
+	err := store.execTX(ctx, func(q *Queries) error {
+		cashAccount, err := getCashAccount(ctx, q, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		result, err = transferFunds(ctx, q, TransactionTxParams{
+			FromAccountID: arg.AccountID,
+			ToAccountID:   cashAccount.ID,
+			Amount:        arg.Amount,
+		}, util.WithdrawalEntryKind)
+		return err
+	})
+
+	return result, err
+}
+
+// Finds the cash account in the currency of the given customer account
+func getCashAccount(ctx context.Context, q *Queries, accountID int64) (Account, error) {
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if util.IsSystemAccount(account.Kind) {
+		return Account{}, fmt.Errorf("%w: account [%d] is a system account", ErrAccountCannotTransfer, account.ID)
+	}
+
+	cashAccount, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+		Kind:     util.CashAccountKind,
+		Currency: account.Currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Account{}, fmt.Errorf("%w: %s", ErrNoCashAccount, account.Currency)
+		}
+		return Account{}, err
+	}
+	return cashAccount, nil
+}
@@ -0,0 +1,34 @@
+package db
+
+import "context"
+
+type ChangePasswordTxParams struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+// Sets the new password, invalidates any outstanding reset tokens, which were
+// requested for the old password, and logs the user out everywhere
+func (store *SQLStore) ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (User, error) {
+	var user User
+
+	err := store.execTX(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:       arg.Username,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.UseUserPasswordResetTokens(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		return revokeUserTokensAndSessions(ctx, q, arg.Username)
+	})
+
+	return user, err
+}
@@ -0,0 +1,46 @@
+package db
+
+import "context"
+
+type EnableTOTPTxParams struct {
+	Username            string   `json:"username"`
+	HashedRecoveryCodes []string `json:"hashed_recovery_codes"`
+	// Step of the code that confirmed the enrollment, it cannot be used to log in
+	TotpStep int64 `json:"totp_step"`
+}
+
+// Turns on TOTP for the user and replaces any previous recovery codes
+func (store *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error) {
+	var user User
+
+	err := store.execTX(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.EnableUserTOTP(ctx, EnableUserTOTPParams{
+			Username:     arg.Username,
+			TotpLastStep: arg.TotpStep,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, hashedCode := range arg.HashedRecoveryCodes {
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username:   arg.Username,
+				HashedCode: hashedCode,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return user, err
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/db/util"
+)
+
+type ReconcileTxParams struct {
+	// Freeze accounts whose balance is not explained by their records
+	FreezeAccounts bool `json:"freeze_accounts"`
+}
+
+type ReconcileTxResult struct {
+	Run    ReconciliationRun     `json:"run"`
+	Drifts []ReconciliationDrift `json:"drifts"`
+}
+
+// Recomputes the balance of every customer account from its records and
+// stores a run with the accounts that do not match. Balances and records are
+// read by a single statement, so transfers in flight cannot show up as drift.
+func (store *SQLStore) ReconcileTx(ctx context.Context, arg ReconcileTxParams) (ReconcileTxResult, error) {
+	var result ReconcileTxResult
+
+	err := store.execTX(ctx, func(q *Queries) error {
+		rows, err := q.ListBalanceDrifts(ctx)
+		if err != nil {
+			return err
+		}
+
+		frozen := make([]bool, len(rows))
+		var frozenAccounts int64
+		for i, row := range rows {
+			if !arg.FreezeAccounts || !util.CanChangeAccountStatus(row.Status, util.FrozenAccountStatus) {
+				continue
+			}
+
+			_, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+				ID:         row.ID,
+				Status:     util.FrozenAccountStatus,
+				FromStatus: row.Status,
+			})
+			if err != nil {
+				// The status changed since the drift was read, leave it to the next run
+				if err == sql.ErrNoRows {
+					continue
+				}
+				return err
+			}
+			frozen[i] = true
+			frozenAccounts++
+		}
+
+		result.Run, err = q.CreateReconciliationRun(ctx, CreateReconciliationRunParams{
+			DriftedAccounts: int64(len(rows)),
+			FrozenAccounts:  frozenAccounts,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Drifts = make([]ReconciliationDrift, len(rows))
+		for i, row := range rows {
+			result.Drifts[i], err = q.CreateReconciliationDrift(ctx, CreateReconciliationDriftParams{
+				RunID:          result.Run.ID,
+				AccountID:      row.ID,
+				Currency:       row.Currency,
+				Balance:        row.Balance,
+				RecordsBalance: row.RecordsBalance,
+				Drift:          row.Balance - row.RecordsBalance,
+				Frozen:         frozen[i],
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// Totals the drift of a run per currency
+func SumDriftByCurrency(drifts []ReconciliationDrift) map[string]int64 {
+	sums := make(map[string]int64)
+	for _, drift := range drifts {
+		sums[drift.Currency] += drift.Drift
+	}
+	return sums
+}
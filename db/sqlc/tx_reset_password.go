@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrPasswordResetTokenUsed = errors.New("Password reset token has already been used")
+
+type ResetPasswordTxParams struct {
+	TokenID        int64  `json:"token_id"`
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+// Consumes the reset token, sets the new password, invalidates any other
+// outstanding reset tokens of the user and logs them out everywhere, since
+// whoever held the old password must not stay logged in
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	var user User
+
+	err := store.execTX(ctx, func(q *Queries) error {
+		rows, err := q.UsePasswordResetToken(ctx, arg.TokenID)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrPasswordResetTokenUsed
+		}
+
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:       arg.Username,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.UseUserPasswordResetTokens(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		return revokeUserTokensAndSessions(ctx, q, arg.Username)
+	})
+
+	return user, err
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"simplebank/db/util"
+)
+
+// ErrTransactionNotReversible is wrapped with the reason a transaction cannot
+// be sent back, e.g. it is a reversal itself
+var ErrTransactionNotReversible = errors.New("transaction cannot be reversed")
+
+// ErrReversalExceedsTransaction is wrapped with what is left to refund when a
+// reversal asks for more
+var ErrReversalExceedsTransaction = errors.New("reversal exceeds the transaction")
+
+type ReverseTransactionTxParams struct {
+	TransactionID int64 `json:"transaction_id"`
+	// In the currency of the from account of the transaction, zero refunds all
+	// that is left of it
+	Amount int64 `json:"amount"`
+}
+
+type ReverseTransactionTxResult struct {
+	Original Transaction `json:"original"`
+	// Goes from the to account of the original back to its from account
+	Reversal TransactionTxResult `json:"reversal"`
+	// Left to refund after this reversal
+	Refundable int64 `json:"refundable"`
+}
+
+func (store *SQLStore) ReverseTransactionTx(ctx context.Context, arg ReverseTransactionTxParams) (ReverseTransactionTxResult, error) {
+	var result ReverseTransactionTxResult
+
+	err := store.execTX(ctx, func(q *Queries) error {
+		var err error
+		result, err = reverseTransaction(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// Sends a transfer back, fully or in part, as a new transaction linked to it.
+// The to account gives up its share of what it received and the from account
+// gets the amount back at the rate of the transfer, so a full reversal puts
+// both sides back where they were. Locking the transaction serializes its
+// reversals, so together they never refund more than it sent.
+func reverseTransaction(ctx context.Context, q *Queries, arg ReverseTransactionTxParams) (ReverseTransactionTxResult, error) {
+	var result ReverseTransactionTxResult
+
+	original, err := q.GetTransactionForUpdate(ctx, arg.TransactionID)
+	if err != nil {
+		return result, err
+	}
+	result.Original = original
+
+	if original.ReversalOf.Valid {
+		return result, fmt.Errorf("%w: transaction [%d] is a reversal of transaction [%d]", ErrTransactionNotReversible, original.ID, original.ReversalOf.Int64)
+	}
+
+	reversalOf := sql.NullInt64{Int64: original.ID, Valid: true}
+	reversed, err := q.SumTransactionReversals(ctx, reversalOf)
+	if err != nil {
+		return result, err
+	}
+
+	refundable := original.Amount - reversed.Refunded
+	amount := arg.Amount
+	if amount == 0 {
+		amount = refundable
+	}
+	if amount <= 0 || amount > refundable {
+		return result, fmt.Errorf("%w: transaction [%d] has %d left to refund", ErrReversalExceedsTransaction, original.ID, refundable)
+	}
+
+	// The money goes back the way it came
+	fromAccount, toAccount, err := lockTransferAccounts(ctx, q, original.ToAccountID, original.FromAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	// Cash deposits and withdrawals are undone at the counter
+	if util.IsSystemAccount(fromAccount.Kind) || util.IsSystemAccount(toAccount.Kind) {
+		return result, fmt.Errorf("%w: transaction [%d] is not a transfer", ErrTransactionNotReversible, original.ID)
+	}
+
+	// Prorating the running total takes exactly what was received by the last refund
+	debit := util.ProrateAmount(original.ToAmount, reversed.Refunded+amount, original.Amount) - reversed.Debited
+	if debit <= 0 {
+		return result, fmt.Errorf("%w: %v", ErrCurrencyConversion, util.ErrAmountTooSmall)
+	}
+
+	if err = checkFunds(fromAccount, debit); err != nil {
+		return result, err
+	}
+
+	conversion := transferConversion{toAmount: amount}
+	if original.ExchangeRate.Valid {
+		rate, err := util.InvertExchangeRate(original.ExchangeRate.String)
+		if err != nil {
+			return result, fmt.Errorf("%w: %v", ErrCurrencyConversion, err)
+		}
+		conversion.exchangeRate = sql.NullString{String: rate, Valid: true}
+
+		if err = conversion.findFXAccounts(ctx, q, fromAccount.Currency, toAccount.Currency); err != nil {
+			return result, err
+		}
+	}
+
+	result.Reversal.Transaction, err = q.CreateTransaction(ctx, CreateTransactionParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        debit,
+		ToAmount:      conversion.toAmount,
+		ExchangeRate:  conversion.exchangeRate,
+		ReversalOf:    reversalOf,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Reversal.FromRecord, result.Reversal.ToRecord, err = createTransactionRecords(ctx, q, result.Reversal.Transaction)
+	if err != nil {
+		return result, err
+	}
+
+	var accounts []Account
+	result.Reversal.JournalEntry, accounts, err = postJournalEntry(ctx, q, JournalEntryParams{
+		Kind:          util.ReversalEntryKind,
+		TransactionID: sql.NullInt64{Int64: result.Reversal.Transaction.ID, Valid: true},
+		Postings:      conversion.postings(fromAccount, toAccount, debit),
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Reversal.FromAccount, result.Reversal.ToAccount = accounts[0], accounts[len(accounts)-1]
+	result.Refundable = refundable - amount
+	return result, nil
+}
@@ -0,0 +1,26 @@
+package db
+
+import "context"
+
+// Rejects every token issued to the user so far, blocks all of their sessions
+// and revokes their API keys
+func (store *SQLStore) RevokeUserTokensTx(ctx context.Context, username string) error {
+	return store.execTX(ctx, func(q *Queries) error {
+		return revokeUserTokensAndSessions(ctx, q, username)
+	})
+}
+
+func revokeUserTokensAndSessions(ctx context.Context, q *Queries, username string) error {
+	err := q.RevokeUserTokens(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	err = q.BlockUserSessions(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	// A key minted from a stolen session must not outlive the session
+	return q.RevokeUserApiKeys(ctx, username)
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"simplebank/db/util"
+	"time"
+)
+
+type ExecuteStandingOrderTxParams struct {
+	StandingOrderID int64     `json:"standing_order_id"`
+	Now             time.Time `json:"now"`
+	FXSpread        string    `json:"fx_spread"`
+	// Transient failures are retried after this delay until an occurrence
+	// has been attempted MaxAttempts times
+	RetryDelay  time.Duration `json:"retry_delay"`
+	MaxAttempts int32         `json:"max_attempts"`
+	// Orders are suspended once this many occurrences in a row fail for lack of funds
+	MaxInsufficientFunds int32 `json:"max_insufficient_funds"`
+}
+
+type ExecuteStandingOrderTxResult struct {
+	StandingOrder StandingOrder `json:"standing_order"`
+	// Empty when the order was not due anymore, e.g. another worker ran it
+	Run      StandingOrderRun    `json:"run"`
+	Transfer TransactionTxResult `json:"transfer"`
+}
+
+// Pays the current occurrence of a due standing order and moves it on to the
+// next one. The transfer and the progress are committed together, so an
+// occurrence is never paid twice. A failed transfer is rolled back and the
+// failure recorded afterwards, in its own transaction.
+func (store *SQLStore) ExecuteStandingOrderTx(ctx context.Context, arg ExecuteStandingOrderTxParams) (ExecuteStandingOrderTxResult, error) {
+	var result ExecuteStandingOrderTxResult
+	var order StandingOrder
+	var attempted bool
+
+	transferErr := store.execTX(ctx, func(q *Queries) error {
+		var err error
+		order, err = q.GetStandingOrderForUpdate(ctx, arg.StandingOrderID)
+		if err != nil {
+			return err
+		}
+		if !standingOrderDue(order, arg.Now) {
+			result.StandingOrder = order
+			return nil
+		}
+
+		attempted = true
+		result.Transfer, err = transferFunds(ctx, q, TransactionTxParams{
+			FromAccountID: order.FromAccountID,
+			ToAccountID:   order.ToAccountID,
+			Amount:        order.Amount,
+			FXSpread:      arg.FXSpread,
+		}, util.TransferEntryKind)
+		if err != nil {
+			return err
+		}
+
+		result.Run, err = q.CreateStandingOrderRun(ctx, CreateStandingOrderRunParams{
+			StandingOrderID: order.ID,
+			OccurrenceAt:    order.OccurrenceAt,
+			Attempt:         order.Attempts + 1,
+			Status:          util.SucceededStandingOrderRun,
+			TransactionID:   sql.NullInt64{Int64: result.Transfer.Transaction.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		progress, err := advanceStandingOrder(order)
+		if err != nil {
+			return err
+		}
+		progress.InsufficientFundsFailures = 0
+
+		result.StandingOrder, err = q.UpdateStandingOrderProgress(ctx, progress)
+		return err
+	})
+	if transferErr == nil || !attempted {
+		return result, transferErr
+	}
+
+	result.Transfer = TransactionTxResult{}
+	err := store.execTX(ctx, func(q *Queries) error {
+		current, err := q.GetStandingOrderForUpdate(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		// Another worker got to the attempt while the transfer was rolled back
+		if !standingOrderDue(current, arg.Now) || !current.OccurrenceAt.Equal(order.OccurrenceAt) || current.Attempts != order.Attempts {
+			result.StandingOrder = current
+			return nil
+		}
+
+		runStatus, progress, err := failStandingOrder(current, transferErr, arg)
+		if err != nil {
+			return err
+		}
+
+		result.Run, err = q.CreateStandingOrderRun(ctx, CreateStandingOrderRunParams{
+			StandingOrderID: current.ID,
+			OccurrenceAt:    current.OccurrenceAt,
+			Attempt:         current.Attempts + 1,
+			Status:          runStatus,
+			Error:           transferErr.Error(),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.StandingOrder, err = q.UpdateStandingOrderProgress(ctx, progress)
+		return err
+	})
+
+	return result, err
+}
+
+func standingOrderDue(order StandingOrder, now time.Time) bool {
+	return order.Status == util.ActiveStandingOrderStatus && !order.NextRunAt.After(now)
+}
+
+// Decides what a failed attempt means for the order. Lack of funds or a
+// transfer limit skip the occurrence, an account that cannot transfer or a
+// missing exchange rate need the owner to step in, anything else is retried
+// a few times.
+func failStandingOrder(order StandingOrder, transferErr error, arg ExecuteStandingOrderTxParams) (string, UpdateStandingOrderProgressParams, error) {
+	switch {
+	case errors.Is(transferErr, ErrInsufficientFunds):
+		progress, err := advanceStandingOrder(order)
+		progress.InsufficientFundsFailures = order.InsufficientFundsFailures + 1
+		if progress.InsufficientFundsFailures >= arg.MaxInsufficientFunds && progress.Status == util.ActiveStandingOrderStatus {
+			progress.Status = util.SuspendedStandingOrderStatus
+		}
+		return util.FailedStandingOrderRun, progress, err
+
+	case errors.Is(transferErr, ErrTransferLimitExceeded):
+		progress, err := advanceStandingOrder(order)
+		return util.FailedStandingOrderRun, progress, err
+
+	case errors.Is(transferErr, ErrAccountCannotTransfer) || errors.Is(transferErr, ErrCurrencyConversion):
+		progress := currentStandingOrderProgress(order)
+		progress.Status = util.SuspendedStandingOrderStatus
+		progress.Attempts = order.Attempts + 1
+		return util.FailedStandingOrderRun, progress, nil
+	}
+
+	if order.Attempts+1 >= arg.MaxAttempts {
+		progress, err := advanceStandingOrder(order)
+		return util.FailedStandingOrderRun, progress, err
+	}
+
+	progress := currentStandingOrderProgress(order)
+	progress.Attempts = order.Attempts + 1
+	progress.NextRunAt = arg.Now.Add(arg.RetryDelay)
+	return util.RetryingStandingOrderRun, progress, nil
+}
+
+func currentStandingOrderProgress(order StandingOrder) UpdateStandingOrderProgressParams {
+	return UpdateStandingOrderProgressParams{
+		ID:                        order.ID,
+		Status:                    order.Status,
+		OccurrenceAt:              order.OccurrenceAt,
+		NextRunAt:                 order.NextRunAt,
+		Attempts:                  order.Attempts,
+		Occurrences:               order.Occurrences,
+		InsufficientFundsFailures: order.InsufficientFundsFailures,
+	}
+}
+
+// Moves the order past its current occurrence. It completes once it has no
+// schedule, reached its max occurrences or the next one is after its end.
+// Occurrences missed while the job was down are still paid, one per run.
+func advanceStandingOrder(order StandingOrder) (UpdateStandingOrderProgressParams, error) {
+	progress := currentStandingOrderProgress(order)
+	progress.Attempts = 0
+	progress.Occurrences = order.Occurrences + 1
+
+	if order.Schedule == "" || (order.MaxOccurrences.Valid && progress.Occurrences >= order.MaxOccurrences.Int32) {
+		progress.Status = util.CompletedStandingOrderStatus
+		return progress, nil
+	}
+
+	schedule, err := util.ParseSchedule(order.Schedule)
+	if err != nil {
+		return progress, err
+	}
+
+	// Zero when a cron expression has no time left to match
+	next := schedule.Next(order.OccurrenceAt.UTC())
+	if next.IsZero() || (order.EndAt.Valid && next.After(order.EndAt.Time)) {
+		progress.Status = util.CompletedStandingOrderStatus
+		return progress, nil
+	}
+
+	progress.OccurrenceAt = next
+	progress.NextRunAt = next
+	return progress, nil
+}
@@ -0,0 +1,367 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"simplebank/db/util"
+	"sort"
+)
+
+// ErrAccountNotFound is wrapped with the account a batch line sends to when
+// it does not exist
+var ErrAccountNotFound = errors.New("account not found")
+
+type BatchTransferLine struct {
+	ToAccountID int64 `json:"to_account_id"`
+	// In the currency of the from account of the batch
+	Amount int64 `json:"amount"`
+}
+
+type BatchTransferTxParams struct {
+	Username      string              `json:"username"`
+	FromAccountID int64               `json:"from_account_id"`
+	Mode          string              `json:"mode"`
+	FXSpread      string              `json:"fx_spread"`
+	Lines         []BatchTransferLine `json:"lines"`
+}
+
+type BatchTransferTxResult struct {
+	Batch TransferBatch       `json:"batch"`
+	Lines []TransferBatchLine `json:"lines"`
+}
+
+// Sends a batch of transfers from one account and records the outcome of
+// every line, so the report can be fetched later. Every line is validated up
+// front. An all or nothing batch only runs when every line is valid, and
+// runs them all in one transaction. A best effort batch runs each valid line
+// in its own transaction and carries on past the ones that fail.
+func (store *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	lineErrs, err := validateBatchTransfers(ctx, store.Queries, arg)
+	if err != nil {
+		return BatchTransferTxResult{}, err
+	}
+
+	if arg.Mode == util.AllOrNothingBatchMode {
+		return store.batchTransferAllOrNothing(ctx, arg, lineErrs)
+	}
+	return store.batchTransferBestEffort(ctx, arg, lineErrs)
+}
+
+func (store *SQLStore) batchTransferAllOrNothing(ctx context.Context, arg BatchTransferTxParams, lineErrs []error) (BatchTransferTxResult, error) {
+	for _, lineErr := range lineErrs {
+		if lineErr != nil {
+			return store.failBatchTransfer(ctx, arg, lineErrs)
+		}
+	}
+
+	var result BatchTransferTxResult
+	err := store.execTX(ctx, func(q *Queries) error {
+		batch, err := q.CreateTransferBatch(ctx, CreateTransferBatchParams{
+			Username:      arg.Username,
+			FromAccountID: arg.FromAccountID,
+			Mode:          arg.Mode,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err = lockBatchTransferAccounts(ctx, q, arg); err != nil {
+			return err
+		}
+
+		result.Lines = make([]TransferBatchLine, len(arg.Lines))
+		for i := range arg.Lines {
+			result.Lines[i], err = runBatchTransferLine(ctx, q, batch, arg, i)
+			if err != nil {
+				lineErrs[i] = err
+				return err
+			}
+		}
+
+		result.Batch, err = q.FinishTransferBatch(ctx, FinishTransferBatchParams{
+			ID:        batch.ID,
+			Status:    util.CompletedBatchStatus,
+			Succeeded: int32(len(arg.Lines)),
+		})
+		return err
+	})
+	if err != nil && isBatchLineFailure(err) {
+		// Balances moved since the validation
+		return store.failBatchTransfer(ctx, arg, lineErrs)
+	}
+
+	return result, err
+}
+
+func (store *SQLStore) batchTransferBestEffort(ctx context.Context, arg BatchTransferTxParams, lineErrs []error) (BatchTransferTxResult, error) {
+	var result BatchTransferTxResult
+
+	batch, err := store.CreateTransferBatch(ctx, CreateTransferBatchParams{
+		Username:      arg.Username,
+		FromAccountID: arg.FromAccountID,
+		Mode:          arg.Mode,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Batch = batch
+
+	var succeeded, failed int32
+	result.Lines = make([]TransferBatchLine, len(arg.Lines))
+	for i := range arg.Lines {
+		lineErr := lineErrs[i]
+		if lineErr == nil {
+			lineErr = store.execTX(ctx, func(q *Queries) error {
+				var err error
+				result.Lines[i], err = runBatchTransferLine(ctx, q, batch, arg, i)
+				return err
+			})
+		}
+		if lineErr == nil {
+			succeeded++
+			continue
+		}
+
+		if !isBatchLineFailure(lineErr) {
+			return store.abortBatchTransfer(ctx, result, succeeded, failed, lineErr)
+		}
+
+		result.Lines[i], err = createBatchTransferLine(ctx, store.Queries, batch, arg, i, util.FailedBatchLine, lineErr)
+		if err != nil {
+			return store.abortBatchTransfer(ctx, result, succeeded, failed, err)
+		}
+		failed++
+	}
+
+	status := util.PartiallyCompletedBatchStatus
+	switch {
+	case failed == 0:
+		status = util.CompletedBatchStatus
+	case succeeded == 0:
+		status = util.FailedBatchStatus
+	}
+
+	result.Batch, err = store.FinishTransferBatch(ctx, FinishTransferBatchParams{
+		ID:        batch.ID,
+		Status:    status,
+		Succeeded: succeeded,
+		Failed:    failed,
+	})
+	return result, err
+}
+
+// Marks a best effort batch that stopped on an error that is not about a line
+// as failed, with the counts of the lines run so far, and returns that error
+func (store *SQLStore) abortBatchTransfer(ctx context.Context, result BatchTransferTxResult, succeeded int32, failed int32, batchErr error) (BatchTransferTxResult, error) {
+	batch, err := store.FinishTransferBatch(ctx, FinishTransferBatchParams{
+		ID:        result.Batch.ID,
+		Status:    util.FailedBatchStatus,
+		Succeeded: succeeded,
+		Failed:    failed,
+	})
+	if err == nil {
+		result.Batch = batch
+	}
+	return result, batchErr
+}
+
+// Locks the from account and every account the batch pays in ID order before
+// any line runs. Locking them pair by pair as the lines run could deadlock
+// with another batch paying the same accounts in a different order.
+func lockBatchTransferAccounts(ctx context.Context, q *Queries, arg BatchTransferTxParams) error {
+	ids := []int64{arg.FromAccountID}
+	seen := map[int64]bool{arg.FromAccountID: true}
+	for _, line := range arg.Lines {
+		if !seen[line.ToAccountID] {
+			seen[line.ToAccountID] = true
+			ids = append(ids, line.ToAccountID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		if _, err := q.GetAccountForUpdate(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Records a batch that moved no money, with why each failed line failed
+func (store *SQLStore) failBatchTransfer(ctx context.Context, arg BatchTransferTxParams, lineErrs []error) (BatchTransferTxResult, error) {
+	var result BatchTransferTxResult
+
+	err := store.execTX(ctx, func(q *Queries) error {
+		batch, err := q.CreateTransferBatch(ctx, CreateTransferBatchParams{
+			Username:      arg.Username,
+			FromAccountID: arg.FromAccountID,
+			Mode:          arg.Mode,
+		})
+		if err != nil {
+			return err
+		}
+
+		var failed int32
+		result.Lines = make([]TransferBatchLine, len(arg.Lines))
+		for i, lineErr := range lineErrs {
+			status := util.SkippedBatchLine
+			if lineErr != nil {
+				status = util.FailedBatchLine
+				failed++
+			}
+
+			result.Lines[i], err = createBatchTransferLine(ctx, q, batch, arg, i, status, lineErr)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Batch, err = q.FinishTransferBatch(ctx, FinishTransferBatchParams{
+			ID:     batch.ID,
+			Status: util.FailedBatchStatus,
+			Failed: failed,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// Runs a line of the batch as a transfer and records it as succeeded
+func runBatchTransferLine(ctx context.Context, q *Queries, batch TransferBatch, arg BatchTransferTxParams, index int) (TransferBatchLine, error) {
+	transfer, err := transferFunds(ctx, q, TransactionTxParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.Lines[index].ToAccountID,
+		Amount:        arg.Lines[index].Amount,
+		FXSpread:      arg.FXSpread,
+	}, util.TransferEntryKind)
+	if err != nil {
+		return TransferBatchLine{}, err
+	}
+
+	return q.CreateTransferBatchLine(ctx, CreateTransferBatchLineParams{
+		BatchID:       batch.ID,
+		Line:          int32(index + 1),
+		ToAccountID:   arg.Lines[index].ToAccountID,
+		Amount:        arg.Lines[index].Amount,
+		Status:        util.SucceededBatchLine,
+		TransactionID: sql.NullInt64{Int64: transfer.Transaction.ID, Valid: true},
+	})
+}
+
+func createBatchTransferLine(ctx context.Context, q *Queries, batch TransferBatch, arg BatchTransferTxParams, index int, status string, lineErr error) (TransferBatchLine, error) {
+	line := CreateTransferBatchLineParams{
+		BatchID:     batch.ID,
+		Line:        int32(index + 1),
+		ToAccountID: arg.Lines[index].ToAccountID,
+		Amount:      arg.Lines[index].Amount,
+		Status:      status,
+	}
+	if lineErr != nil {
+		line.Error = lineErr.Error()
+	}
+	return q.CreateTransferBatchLine(ctx, line)
+}
+
+// Failures that belong to a line and are reported, anything else aborts the batch
+func isBatchLineFailure(err error) bool {
+	return errors.Is(err, ErrAccountNotFound) || errors.Is(err, ErrAccountCannotTransfer) ||
+		errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrCurrencyConversion) ||
+		errors.Is(err, ErrTransferLimitExceeded)
+}
+
+// Checks every line against the accounts, rates, funds and transfer limits as
+// they stand, counting the lines before it towards the funds and limits. The
+// locks are only taken when the lines run, which check again.
+func validateBatchTransfers(ctx context.Context, q *Queries, arg BatchTransferTxParams) ([]error, error) {
+	lineErrs := make([]error, len(arg.Lines))
+
+	fromAccount, err := q.GetAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return lineErrs, err
+	}
+	if !util.CanTransferFunds(fromAccount.Status) {
+		for i := range lineErrs {
+			lineErrs[i] = fmt.Errorf("%w: account [%d] is %s", ErrAccountCannotTransfer, fromAccount.ID, fromAccount.Status)
+		}
+		return lineErrs, nil
+	}
+
+	allowance, err := transferAllowance(ctx, q, fromAccount)
+	if err != nil {
+		return lineErrs, err
+	}
+
+	rates := make(map[string]ExchangeRate)
+	available := fromAccount.Balance + fromAccount.OverdraftLimit
+	for i, line := range arg.Lines {
+		if line.ToAccountID == fromAccount.ID {
+			lineErrs[i] = fmt.Errorf("%w: account [%d] is the from account of the batch", ErrAccountCannotTransfer, line.ToAccountID)
+			continue
+		}
+
+		toAccount, err := q.GetAccount(ctx, line.ToAccountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				lineErrs[i] = fmt.Errorf("%w: account [%d]", ErrAccountNotFound, line.ToAccountID)
+				continue
+			}
+			return lineErrs, err
+		}
+
+		// System accounts only move money through ledgered operations
+		if util.IsSystemAccount(toAccount.Kind) {
+			lineErrs[i] = fmt.Errorf("%w: account [%d] is a system account", ErrAccountCannotTransfer, toAccount.ID)
+			continue
+		}
+		if !util.CanTransferFunds(toAccount.Status) {
+			lineErrs[i] = fmt.Errorf("%w: account [%d] is %s", ErrAccountCannotTransfer, toAccount.ID, toAccount.Status)
+			continue
+		}
+
+		if toAccount.Currency != fromAccount.Currency {
+			rate, ok := rates[toAccount.Currency]
+			if !ok {
+				rate, err = q.GetLatestExchangeRate(ctx, GetLatestExchangeRateParams{
+					FromCurrency: fromAccount.Currency,
+					ToCurrency:   toAccount.Currency,
+				})
+				if err != nil {
+					if err == sql.ErrNoRows {
+						lineErrs[i] = fmt.Errorf("%w: no rate from %s to %s", ErrCurrencyConversion, fromAccount.Currency, toAccount.Currency)
+						continue
+					}
+					return lineErrs, err
+				}
+				rates[toAccount.Currency] = rate
+			}
+
+			if _, _, err = util.ConvertAmount(line.Amount, rate.Rate, arg.FXSpread); err != nil {
+				lineErrs[i] = fmt.Errorf("%w: %v", ErrCurrencyConversion, err)
+				continue
+			}
+		}
+
+		if !util.IsSystemAccount(fromAccount.Kind) {
+			if line.Amount > available {
+				lineErrs[i] = fmt.Errorf("%w: account [%d] has %d available", ErrInsufficientFunds, fromAccount.ID, available)
+				continue
+			}
+			if err = allowance.Check(line.Amount); err != nil {
+				lineErrs[i] = err
+				continue
+			}
+		}
+
+		available -= line.Amount
+		for _, remaining := range []*int64{allowance.DailyRemaining, allowance.MonthlyRemaining} {
+			if remaining != nil {
+				*remaining -= line.Amount
+			}
+		}
+	}
+
+	return lineErrs, nil
+}
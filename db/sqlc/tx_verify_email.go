@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrVerifyEmailUsed = errors.New("Email verification token has already been used")
+
+type VerifyEmailTxParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// Consumes the verification token and marks the email of the user as verified.
+// Returns sql.ErrNoRows if the user no longer has the email the token was sent to.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (User, error) {
+	var user User
+
+	err := store.execTX(ctx, func(q *Queries) error {
+		rows, err := q.UseVerifyEmail(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrVerifyEmailUsed
+		}
+
+		user, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			Username: arg.Username,
+			Email:    arg.Email,
+		})
+		return err
+	})
+
+	return user, err
+}
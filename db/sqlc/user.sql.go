@@ -22,7 +22,7 @@ address
 ) VALUES (
 $1, $2, $3, $4, $5, $6, $7
 )
-RETURNING username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at
+RETURNING username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step
 `
 
 type CreateUserParams struct {
@@ -56,12 +56,55 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Address,
 		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE users
+SET totp_enabled = true, totp_last_step = $2, updated_at = now()
+WHERE username = $1 AND totp_secret IS NOT NULL
+RETURNING username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step
+`
+
+type EnableUserTOTPParams struct {
+	Username     string `json:"username"`
+	TotpLastStep int64  `json:"totp_last_step"`
+}
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUserTOTP, arg.Username, arg.TotpLastStep)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.ContactNumber,
+		&i.Address,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at FROM users
+SELECT username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -78,6 +121,287 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Address,
 		&i.UpdatedAt,
 		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.ContactNumber,
+		&i.Address,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :exec
+UPDATE users
+SET tokens_revoked_at = now()
+WHERE username = $1
+`
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, revokeUserTokens, username)
+	return err
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = $2, totp_enabled = false, updated_at = now()
+WHERE username = $1
+RETURNING username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step
+`
+
+type SetUserTOTPSecretParams struct {
+	Username   string         `json:"username"`
+	TotpSecret sql.NullString `json:"totp_secret"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserTOTPSecret, arg.Username, arg.TotpSecret)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.ContactNumber,
+		&i.Address,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET
+    first_name = COALESCE($1, first_name),
+    last_name = COALESCE($2, last_name),
+    email = COALESCE($3, email),
+    is_email_verified = is_email_verified AND COALESCE($3, email) = email,
+    contact_number = COALESCE($4, contact_number),
+    address = COALESCE($5, address),
+    updated_at = now()
+WHERE username = $6
+RETURNING username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step
+`
+
+type UpdateUserParams struct {
+	FirstName     sql.NullString `json:"first_name"`
+	LastName      sql.NullString `json:"last_name"`
+	Email         sql.NullString `json:"email"`
+	ContactNumber sql.NullString `json:"contact_number"`
+	Address       sql.NullString `json:"address"`
+	Username      string         `json:"username"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.FirstName,
+		arg.LastName,
+		arg.Email,
+		arg.ContactNumber,
+		arg.Address,
+		arg.Username,
+	)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.ContactNumber,
+		&i.Address,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2, updated_at = now()
+WHERE username = $1
+RETURNING username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step
+`
+
+type UpdateUserPasswordParams struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPassword, arg.Username, arg.HashedPassword)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.ContactNumber,
+		&i.Address,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2, updated_at = now()
+WHERE username = $1
+RETURNING username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step
+`
+
+type UpdateUserRoleParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.Username, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.ContactNumber,
+		&i.Address,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const updateUserTier = `-- name: UpdateUserTier :one
+UPDATE users
+SET tier = $2, updated_at = now()
+WHERE username = $1
+RETURNING username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step
+`
+
+type UpdateUserTierParams struct {
+	Username string `json:"username"`
+	Tier     string `json:"tier"`
+}
+
+func (q *Queries) UpdateUserTier(ctx context.Context, arg UpdateUserTierParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserTier, arg.Username, arg.Tier)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.ContactNumber,
+		&i.Address,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true, updated_at = now()
+WHERE username = $1 AND email = $2
+RETURNING username, hashed_password, first_name, last_name, email, contact_number, address, updated_at, created_at, tokens_revoked_at, role, totp_secret, totp_enabled, is_email_verified, tier, totp_last_step
+`
+
+type VerifyUserEmailParams struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.Username, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FirstName,
+		&i.LastName,
+		&i.Email,
+		&i.ContactNumber,
+		&i.Address,
+		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+		&i.TotpSecret,
+		&i.TotpEnabled,
+		&i.IsEmailVerified,
+		&i.Tier,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
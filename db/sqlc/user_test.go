@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"github.com/stretchr/testify/require"
 	"simplebank/db/util"
 	"testing"
@@ -61,3 +62,103 @@ func TestGetUser(t *testing.T) {
 func TestCreateUser(t *testing.T) {
 	createRandomUser(t)
 }
+
+func TestUpdateUserRole(t *testing.T) {
+	user1 := createRandomUser(t)
+	require.Equal(t, util.CustomerRole, user1.Role)
+
+	user2, err := testQueries.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Username: user1.Username,
+		Role:     util.TellerRole,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, util.TellerRole, user2.Role)
+}
+
+func TestSetUserTOTPSecret(t *testing.T) {
+	user1 := createRandomUser(t)
+	require.False(t, user1.TotpSecret.Valid)
+	require.False(t, user1.TotpEnabled)
+
+	secret := sql.NullString{String: util.RandomString(32), Valid: true}
+	user2, err := testQueries.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		Username:   user1.Username,
+		TotpSecret: secret,
+	})
+	require.NoError(t, err)
+	require.Equal(t, secret, user2.TotpSecret)
+	require.False(t, user2.TotpEnabled)
+}
+
+func TestEnableUserTOTPWithoutSecret(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQueries.EnableUserTOTP(context.Background(), EnableUserTOTPParams{
+		Username:     user.Username,
+		TotpLastStep: time.Now().Unix() / 30,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	user2, err := testQueries.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+}
+
+func TestUpdateUserOnlyContact(t *testing.T) {
+	oldUser := createRandomUser(t)
+
+	arg := UpdateUserParams{
+		Username:      oldUser.Username,
+		ContactNumber: sql.NullString{String: "+14155552671", Valid: true},
+		Address:       sql.NullString{String: "1 Market St, San Francisco", Valid: true},
+	}
+
+	updatedUser, err := testQueries.UpdateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ContactNumber, updatedUser.ContactNumber)
+	require.Equal(t, arg.Address, updatedUser.Address)
+	require.Equal(t, oldUser.FirstName, updatedUser.FirstName)
+	require.Equal(t, oldUser.LastName, updatedUser.LastName)
+	require.Equal(t, oldUser.Email, updatedUser.Email)
+	require.True(t, updatedUser.UpdatedAt.After(oldUser.UpdatedAt))
+}
+
+func TestUpdateUserEmailResetsVerification(t *testing.T) {
+	oldUser := createRandomUser(t)
+	verifiedUser, err := testQueries.VerifyUserEmail(context.Background(), VerifyUserEmailParams{
+		Username: oldUser.Username,
+		Email:    oldUser.Email,
+	})
+	require.NoError(t, err)
+	require.True(t, verifiedUser.IsEmailVerified)
+
+	// Sending the current email again keeps it verified
+	sameUser, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		Email:    sql.NullString{String: oldUser.Email, Valid: true},
+	})
+	require.NoError(t, err)
+	require.True(t, sameUser.IsEmailVerified)
+
+	newEmail := util.RandomEmail()
+	updatedUser, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		Email:    sql.NullString{String: newEmail, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, updatedUser.Email)
+	require.False(t, updatedUser.IsEmailVerified)
+}
+
+func TestUpdateUserNotFound(t *testing.T) {
+	_, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username:  util.RandomUsername(),
+		FirstName: sql.NullString{String: util.RandomUsername(), Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
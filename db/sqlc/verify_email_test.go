@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"simplebank/db/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomVerifyEmail(t *testing.T, user User) VerifyEmail {
+	arg := CreateVerifyEmailParams{
+		Username:    user.Username,
+		Email:       user.Email,
+		HashedToken: util.HashToken(util.RandomString(32)),
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+
+	verifyEmail, err := testQueries.CreateVerifyEmail(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, verifyEmail)
+
+	require.Equal(t, arg.Username, verifyEmail.Username)
+	require.Equal(t, arg.Email, verifyEmail.Email)
+	require.Equal(t, arg.HashedToken, verifyEmail.HashedToken)
+	require.WithinDuration(t, arg.ExpiresAt, verifyEmail.ExpiresAt, time.Second)
+	require.False(t, verifyEmail.UsedAt.Valid)
+
+	return verifyEmail
+}
+
+func TestGetVerifyEmail(t *testing.T) {
+	user := createRandomUser(t)
+	verifyEmail1 := createRandomVerifyEmail(t, user)
+
+	verifyEmail2, err := testQueries.GetVerifyEmail(context.Background(), verifyEmail1.HashedToken)
+	require.NoError(t, err)
+	require.Equal(t, verifyEmail1.ID, verifyEmail2.ID)
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	user1 := createRandomUser(t)
+	require.False(t, user1.IsEmailVerified)
+
+	verifyEmail := createRandomVerifyEmail(t, user1)
+	arg := VerifyEmailTxParams{
+		ID:       verifyEmail.ID,
+		Username: verifyEmail.Username,
+		Email:    verifyEmail.Email,
+	}
+
+	user2, err := store.VerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, user2.IsEmailVerified)
+
+	_, err = store.VerifyEmailTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrVerifyEmailUsed)
+}
+
+func TestVerifyEmailTxEmailChanged(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	verifyEmail := createRandomVerifyEmail(t, user)
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		ID:       verifyEmail.ID,
+		Username: user.Username,
+		Email:    util.RandomEmail(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// The token is not spent when verification fails
+	verifyEmail, err = testQueries.GetVerifyEmail(context.Background(), verifyEmail.HashedToken)
+	require.NoError(t, err)
+	require.False(t, verifyEmail.UsedAt.Valid)
+}
@@ -0,0 +1,30 @@
+package util
+
+const (
+	// Supported Account Status
+	ActiveAccountStatus  = "active"
+	FrozenAccountStatus  = "frozen"
+	DormantAccountStatus = "dormant"
+	ClosedAccountStatus  = "closed"
+)
+
+// Status changes an account may go through. Closed is final.
+var accountStatusTransitions = map[string][]string{
+	ActiveAccountStatus:  {FrozenAccountStatus, DormantAccountStatus, ClosedAccountStatus},
+	DormantAccountStatus: {ActiveAccountStatus, FrozenAccountStatus, ClosedAccountStatus},
+	FrozenAccountStatus:  {ActiveAccountStatus},
+}
+
+func CanChangeAccountStatus(from string, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Frozen and closed accounts can neither send nor receive funds
+func CanTransferFunds(status string) bool {
+	return status == ActiveAccountStatus || status == DormantAccountStatus
+}
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanChangeAccountStatus(t *testing.T) {
+	require.True(t, CanChangeAccountStatus(ActiveAccountStatus, FrozenAccountStatus))
+	require.True(t, CanChangeAccountStatus(FrozenAccountStatus, ActiveAccountStatus))
+	require.True(t, CanChangeAccountStatus(ActiveAccountStatus, ClosedAccountStatus))
+	require.True(t, CanChangeAccountStatus(DormantAccountStatus, ActiveAccountStatus))
+
+	// A frozen account has to be unfrozen before it can be closed
+	require.False(t, CanChangeAccountStatus(FrozenAccountStatus, ClosedAccountStatus))
+	require.False(t, CanChangeAccountStatus(ActiveAccountStatus, ActiveAccountStatus))
+
+	for _, status := range []string{ActiveAccountStatus, FrozenAccountStatus, DormantAccountStatus} {
+		require.False(t, CanChangeAccountStatus(ClosedAccountStatus, status))
+	}
+}
+
+func TestCanTransferFunds(t *testing.T) {
+	require.True(t, CanTransferFunds(ActiveAccountStatus))
+	require.True(t, CanTransferFunds(DormantAccountStatus))
+	require.False(t, CanTransferFunds(FrozenAccountStatus))
+	require.False(t, CanTransferFunds(ClosedAccountStatus))
+	require.False(t, CanTransferFunds(""))
+}
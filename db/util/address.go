@@ -0,0 +1,32 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	minAddressLength = 5
+	maxAddressLength = 200
+)
+
+// Postal addresses are free form, so only reject values that cannot be one:
+// too short or long, surrounded by spaces, or containing control characters
+func IsValidAddress(address string) bool {
+	if strings.TrimSpace(address) != address {
+		return false
+	}
+
+	length := utf8.RuneCountInString(address)
+	if length < minAddressLength || length > maxAddressLength {
+		return false
+	}
+
+	for _, char := range address {
+		if unicode.IsControl(char) {
+			return false
+		}
+	}
+	return true
+}
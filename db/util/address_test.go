@@ -0,0 +1,19 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidAddress(t *testing.T) {
+	require.True(t, IsValidAddress("221B Baker Street, London NW1 6XE"))
+	require.True(t, IsValidAddress("Königsallee 1, Düsseldorf"))
+
+	require.False(t, IsValidAddress(""))
+	require.False(t, IsValidAddress("1 St"))
+	require.False(t, IsValidAddress(" 1 Main Street"))
+	require.False(t, IsValidAddress("1 Main\nStreet"))
+	require.False(t, IsValidAddress(strings.Repeat("a", maxAddressLength+1)))
+}
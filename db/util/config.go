@@ -7,12 +7,58 @@ import (
 )
 
 type Config struct {
-	ApiKey              string        `mapstructure:"EXCHANGE_API_KEY"`
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	ApiKey                  string        `mapstructure:"EXCHANGE_API_KEY"`
+	DBDriver                string        `mapstructure:"DB_DRIVER"`
+	DBSource                string        `mapstructure:"DB_SOURCE"`
+	ServerAddress           string        `mapstructure:"SERVER_ADDRESS"`
+	TrustedProxies          []string      `mapstructure:"TRUSTED_PROXIES"`
+	TokenType               string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymmetricKey       string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenPrivateKey         string        `mapstructure:"TOKEN_PRIVATE_KEY"`
+	TokenPreviousPublicKeys string        `mapstructure:"TOKEN_PREVIOUS_PUBLIC_KEYS"`
+	TokenKeyID              string        `mapstructure:"TOKEN_KEY_ID"`
+	TokenPreviousKeys       string        `mapstructure:"TOKEN_PREVIOUS_KEYS"`
+	AccessTokenDuration     time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration    time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	RevocationCacheTTL      time.Duration `mapstructure:"REVOCATION_CACHE_TTL"`
+	MFAChallengeDuration    time.Duration `mapstructure:"MFA_CHALLENGE_DURATION"`
+	LoginMaxFailures        int32         `mapstructure:"LOGIN_MAX_FAILURES"`
+	LoginMaxIPFailures      int32         `mapstructure:"LOGIN_MAX_IP_FAILURES"`
+	LoginFailureWindow      time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	LoginLockoutDuration    time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginBackoffBase        time.Duration `mapstructure:"LOGIN_BACKOFF_BASE"`
+	MailDriver              string        `mapstructure:"MAIL_DRIVER"`
+	MailFrom                string        `mapstructure:"MAIL_FROM"`
+	MailLogPath             string        `mapstructure:"MAIL_LOG_PATH"`
+	SMTPHost                string        `mapstructure:"SMTP_HOST"`
+	SMTPPort                int           `mapstructure:"SMTP_PORT"`
+	SMTPUsername            string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword            string        `mapstructure:"SMTP_PASSWORD"`
+	PasswordResetURL        string        `mapstructure:"PASSWORD_RESET_URL"`
+	PasswordResetDuration   time.Duration `mapstructure:"PASSWORD_RESET_DURATION"`
+	VerifyEmailURL          string        `mapstructure:"VERIFY_EMAIL_URL"`
+	VerifyEmailDuration     time.Duration `mapstructure:"VERIFY_EMAIL_DURATION"`
+	RequireVerifiedEmail    bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+	PasswordMinLength       int           `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordRequireUpper    bool          `mapstructure:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireLower    bool          `mapstructure:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireDigit    bool          `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol   bool          `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordDenylistPath    string        `mapstructure:"PASSWORD_DENYLIST_PATH"`
+	PasswordHashAlgorithm   string        `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	PasswordBcryptCost      int           `mapstructure:"PASSWORD_BCRYPT_COST"`
+	PasswordArgon2Time      uint32        `mapstructure:"PASSWORD_ARGON2_TIME"`
+	PasswordArgon2Memory    uint32        `mapstructure:"PASSWORD_ARGON2_MEMORY"`
+	PasswordArgon2Threads   uint8         `mapstructure:"PASSWORD_ARGON2_THREADS"`
+	AccountDormancyPeriod   time.Duration `mapstructure:"ACCOUNT_DORMANCY_PERIOD"`
+	ReconciliationSchedule  string        `mapstructure:"RECONCILIATION_SCHEDULE"`
+	ReconciliationFreeze    bool          `mapstructure:"RECONCILIATION_FREEZE_ACCOUNTS"`
+	IdempotencyKeyRetention time.Duration `mapstructure:"IDEMPOTENCY_KEY_RETENTION"`
+	FXSpread                string        `mapstructure:"FX_SPREAD"`
+	StandingOrderSchedule   string        `mapstructure:"STANDING_ORDER_SCHEDULE"`
+	StandingOrderRetryDelay time.Duration `mapstructure:"STANDING_ORDER_RETRY_DELAY"`
+	StandingOrderAttempts   int32         `mapstructure:"STANDING_ORDER_MAX_ATTEMPTS"`
+	StandingOrderNSFLimit   int32         `mapstructure:"STANDING_ORDER_MAX_INSUFFICIENT_FUNDS"`
 }
 
 func LoadViberConfig(path string) (config Config, err error) {
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+)
+
+// Digits kept for rates, matching the scale of the rate columns
+const exchangeRateScale = 10
+
+var ErrAmountTooSmall = errors.New("amount is too small to convert")
+
+// Plain decimals only, big.Rat would also take fractions and exponents
+var decimalRate = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Parses a decimal rate such as 0.92, which must be positive
+func ParseExchangeRate(rate string) (*big.Rat, error) {
+	if !decimalRate.MatchString(rate) {
+		return nil, fmt.Errorf("invalid exchange rate %q", rate)
+	}
+	value, ok := new(big.Rat).SetString(rate)
+	if !ok || value.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q", rate)
+	}
+	return value, nil
+}
+
+// Parses a spread given as a fraction, e.g. 0.005 for half a percent. An
+// empty spread is no spread.
+func ParseSpread(spread string) (*big.Rat, error) {
+	if spread == "" {
+		return new(big.Rat), nil
+	}
+	value, ok := new(big.Rat).SetString(spread)
+	if !ok || value.Sign() < 0 || value.Cmp(big.NewRat(1, 1)) >= 0 {
+		return nil, fmt.Errorf("invalid exchange spread %q", spread)
+	}
+	return value, nil
+}
+
+// Converts an amount at the rate less the spread, rounding down so that the
+// bank never pays out more than the rate allows. Returns the converted amount
+// and the rate that was applied.
+func ConvertAmount(amount int64, rate string, spread string) (int64, string, error) {
+	midRate, err := ParseExchangeRate(rate)
+	if err != nil {
+		return 0, "", err
+	}
+	spreadValue, err := ParseSpread(spread)
+	if err != nil {
+		return 0, "", err
+	}
+
+	appliedRate := new(big.Rat).Sub(big.NewRat(1, 1), spreadValue)
+	appliedRate.Mul(appliedRate, midRate)
+
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), appliedRate)
+	toAmount := new(big.Int).Quo(converted.Num(), converted.Denom())
+	if toAmount.Sign() <= 0 {
+		return 0, "", ErrAmountTooSmall
+	}
+	if !toAmount.IsInt64() {
+		return 0, "", fmt.Errorf("converted amount %s is out of range", toAmount)
+	}
+
+	return toAmount.Int64(), appliedRate.FloatString(exchangeRateScale), nil
+}
+
+// Inverts a rate, for sending money back the way it came
+func InvertExchangeRate(rate string) (string, error) {
+	value, err := ParseExchangeRate(rate)
+	if err != nil {
+		return "", err
+	}
+	return value.Inv(value).FloatString(exchangeRateScale), nil
+}
+
+// Works out the share of total that part is of whole, rounding down. Part
+// may be a running total, whose share ends at total once it reaches whole.
+func ProrateAmount(total int64, part int64, whole int64) int64 {
+	share := new(big.Int).Mul(big.NewInt(total), big.NewInt(part))
+	return share.Quo(share, big.NewInt(whole)).Int64()
+}
@@ -0,0 +1,107 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertAmount(t *testing.T) {
+	testCases := []struct {
+		name        string
+		amount      int64
+		rate        string
+		spread      string
+		toAmount    int64
+		appliedRate string
+		err         bool
+	}{
+		{
+			name:        "No Spread",
+			amount:      1000,
+			rate:        "7.8",
+			spread:      "",
+			toAmount:    7800,
+			appliedRate: "7.8000000000",
+		},
+		{
+			name:        "With Spread",
+			amount:      1000,
+			rate:        "7.8",
+			spread:      "0.01",
+			toAmount:    7722,
+			appliedRate: "7.7220000000",
+		},
+		{
+			name:        "Rounds Down",
+			amount:      10,
+			rate:        "0.1285",
+			spread:      "0",
+			toAmount:    1,
+			appliedRate: "0.1285000000",
+		},
+		{
+			name:   "Too Small",
+			amount: 5,
+			rate:   "0.1285",
+			spread: "0",
+			err:    true,
+		},
+		{
+			name:   "Invalid Rate",
+			amount: 100,
+			rate:   "-1",
+			err:    true,
+		},
+		{
+			name:   "Invalid Spread",
+			amount: 100,
+			rate:   "1.1",
+			spread: "1",
+			err:    true,
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			toAmount, appliedRate, err := ConvertAmount(testCase.amount, testCase.rate, testCase.spread)
+			if testCase.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.toAmount, toAmount)
+			require.Equal(t, testCase.appliedRate, appliedRate)
+		})
+	}
+}
+
+func TestParseExchangeRate(t *testing.T) {
+	for _, rate := range []string{"1", "0.92", "7.8000000000"} {
+		_, err := ParseExchangeRate(rate)
+		require.NoError(t, err, rate)
+	}
+
+	for _, rate := range []string{"", "0", "-1", "1/3", "1e3", ".5", "1.", " 1"} {
+		_, err := ParseExchangeRate(rate)
+		require.Error(t, err, rate)
+	}
+}
+
+func TestInvertExchangeRate(t *testing.T) {
+	rate, err := InvertExchangeRate("0.8")
+	require.NoError(t, err)
+	require.Equal(t, "1.2500000000", rate)
+
+	_, err = InvertExchangeRate("0")
+	require.Error(t, err)
+}
+
+func TestProrateAmount(t *testing.T) {
+	require.Equal(t, int64(296), ProrateAmount(891, 333, 1000))
+	require.Equal(t, int64(594), ProrateAmount(891, 667, 1000))
+	require.Equal(t, int64(891), ProrateAmount(891, 1000, 1000))
+	require.Equal(t, int64(0), ProrateAmount(891, 1, 1000))
+}
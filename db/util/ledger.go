@@ -0,0 +1,24 @@
+package util
+
+const (
+	// Supported Account Kinds, all but customer are system accounts
+	CustomerAccountKind = "customer"
+	CashAccountKind     = "cash"
+	FeesAccountKind     = "fees"
+	FXAccountKind       = "fx"
+	SuspenseAccountKind = "suspense"
+)
+
+const (
+	// Supported Journal Entry Kinds
+	TransferEntryKind   = "transfer"
+	DepositEntryKind    = "deposit"
+	WithdrawalEntryKind = "withdrawal"
+	ReversalEntryKind   = "reversal"
+)
+
+// System accounts belong to the bank. They stand for money outside of
+// customer accounts, so they may go negative and never run out of funds.
+func IsSystemAccount(kind string) bool {
+	return kind != CustomerAccountKind
+}
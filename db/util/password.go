@@ -1,18 +1,209 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 )
 
+// Supported password hashing algorithms
+const (
+	Argon2idAlgorithm = "argon2id"
+	BcryptAlgorithm   = "bcrypt"
+)
+
+// Shared with bcrypt so that callers see the same error whatever algorithm produced the hash
+var ErrMismatchedPassword = bcrypt.ErrMismatchedHashAndPassword
+
+var errUnknownPasswordHash = errors.New("unknown password hash format")
+
+// Argon2Params are the argon2id cost parameters. Memory is in KiB.
+type Argon2Params struct {
+	Time       uint32
+	Memory     uint32
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// DefaultArgon2Params follow the OWASP recommendation for argon2id
+var DefaultArgon2Params = Argon2Params{
+	Time:       2,
+	Memory:     19 * 1024,
+	Threads:    1,
+	SaltLength: 16,
+	KeyLength:  32,
+}
+
+// PasswordHasher hashes new passwords with its configured algorithm and cost,
+// and verifies hashes of any supported algorithm. Hashes are self-describing,
+// so a hash made with older settings can be spotted and upgraded on login.
+type PasswordHasher struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// DefaultPasswordHasher is used by HashPassword
+var DefaultPasswordHasher = &PasswordHasher{
+	Algorithm:  Argon2idAlgorithm,
+	BcryptCost: bcrypt.DefaultCost,
+	Argon2:     DefaultArgon2Params,
+}
+
+// Builds the hasher described by the config, unset values keep their default
+func NewPasswordHasher(config Config) (*PasswordHasher, error) {
+	hasher := *DefaultPasswordHasher
+
+	if config.PasswordHashAlgorithm != "" {
+		hasher.Algorithm = config.PasswordHashAlgorithm
+	}
+	if config.PasswordBcryptCost != 0 {
+		hasher.BcryptCost = config.PasswordBcryptCost
+	}
+	if config.PasswordArgon2Time != 0 {
+		hasher.Argon2.Time = config.PasswordArgon2Time
+	}
+	if config.PasswordArgon2Memory != 0 {
+		hasher.Argon2.Memory = config.PasswordArgon2Memory
+	}
+	if config.PasswordArgon2Threads != 0 {
+		hasher.Argon2.Threads = config.PasswordArgon2Threads
+	}
+
+	switch hasher.Algorithm {
+	case Argon2idAlgorithm:
+	case BcryptAlgorithm:
+		if hasher.BcryptCost < bcrypt.MinCost || hasher.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid bcrypt cost %d", hasher.BcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", hasher.Algorithm)
+	}
+
+	return &hasher, nil
+}
+
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return DefaultPasswordHasher.Hash(password)
+}
+
+// Checks the password against a hash made by any supported algorithm
+func ValidPassword(password string, hashedPassword string) error {
+	if strings.HasPrefix(hashedPassword, "$argon2id$") {
+		return validArgon2idPassword(password, hashedPassword)
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+}
+
+func (hasher *PasswordHasher) Hash(password string) (string, error) {
+	var hashedPassword string
+	var err error
+
+	switch hasher.Algorithm {
+	case BcryptAlgorithm:
+		var hash []byte
+		hash, err = bcrypt.GenerateFromPassword([]byte(password), hasher.BcryptCost)
+		hashedPassword = string(hash)
+	default:
+		hashedPassword, err = hashArgon2idPassword(password, hasher.Argon2)
+	}
+
 	if err != nil {
 		return "", fmt.Errorf("Failed to hash the password: %w", err)
 	}
-	return string(hashedPassword), nil
+	return hashedPassword, nil
 }
 
-func ValidPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+// Reports whether the hash was made with another algorithm or cost than the
+// hasher currently uses and should be replaced once the password is known
+func (hasher *PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	switch hasher.Algorithm {
+	case BcryptAlgorithm:
+		cost, err := bcrypt.Cost([]byte(hashedPassword))
+		return err != nil || cost != hasher.BcryptCost
+	default:
+		params, _, _, err := decodeArgon2idHash(hashedPassword)
+		if err != nil {
+			return true
+		}
+		return params.Time != hasher.Argon2.Time ||
+			params.Memory != hasher.Argon2.Memory ||
+			params.Threads != hasher.Argon2.Threads ||
+			params.KeyLength != hasher.Argon2.KeyLength
+	}
+}
+
+// Encodes the hash in the PHC string format, e.g. $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+func hashArgon2idPassword(password string, params Argon2Params) (string, error) {
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.Memory,
+		params.Time,
+		params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func validArgon2idPassword(password string, hashedPassword string) error {
+	params, salt, key, err := decodeArgon2idHash(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func decodeArgon2idHash(hashedPassword string) (params Argon2Params, salt []byte, key []byte, err error) {
+	fields := strings.Split(hashedPassword, "$")
+	if len(fields) != 6 || fields[1] != Argon2idAlgorithm {
+		err = errUnknownPasswordHash
+		return
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(fields[2], "v=%d", &version); err != nil || version != argon2.Version {
+		err = errUnknownPasswordHash
+		return
+	}
+
+	_, err = fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads)
+	if err != nil {
+		err = errUnknownPasswordHash
+		return
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil {
+		err = errUnknownPasswordHash
+		return
+	}
+
+	key, err = base64.RawStdEncoding.DecodeString(fields[5])
+	if err != nil || len(key) == 0 {
+		err = errUnknownPasswordHash
+		return
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return
 }
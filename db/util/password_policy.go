@@ -0,0 +1,120 @@
+package util
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// Passwords were never allowed to be shorter than this
+	minPasswordLength = 6
+	// Long enough for passphrases while bounding the cost of hashing
+	maxPasswordLength = 128
+)
+
+// PasswordPolicy decides which new passwords are acceptable. It is only
+// applied when a password is set, existing passwords keep working.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Lower cased common or breached passwords that are always rejected
+	denylist map[string]bool
+}
+
+// Builds the policy described by the config and loads its denylist file, if any
+func NewPasswordPolicy(config Config) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		MinLength:     config.PasswordMinLength,
+		RequireUpper:  config.PasswordRequireUpper,
+		RequireLower:  config.PasswordRequireLower,
+		RequireDigit:  config.PasswordRequireDigit,
+		RequireSymbol: config.PasswordRequireSymbol,
+		denylist:      make(map[string]bool),
+	}
+
+	if policy.MinLength < minPasswordLength {
+		policy.MinLength = minPasswordLength
+	}
+	if policy.MinLength > maxPasswordLength {
+		return nil, fmt.Errorf("password min length cannot exceed %d", maxPasswordLength)
+	}
+
+	if config.PasswordDenylistPath != "" {
+		err := policy.loadDenylist(config.PasswordDenylistPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return policy, nil
+}
+
+// Reads one password per line, blank lines and lines starting with # are skipped
+func (policy *PasswordPolicy) loadDenylist(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open password denylist: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		policy.denylist[strings.ToLower(line)] = true
+	}
+	return scanner.Err()
+}
+
+// Returns an error describing the first rule the password breaks
+func (policy *PasswordPolicy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < policy.MinLength {
+		return fmt.Errorf("Password must be at least %d characters long", policy.MinLength)
+	}
+	if length > maxPasswordLength {
+		return fmt.Errorf("Password must be at most %d characters long", maxPasswordLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char) || unicode.IsSpace(char):
+			hasSymbol = true
+		}
+	}
+
+	if policy.RequireUpper && !hasUpper {
+		return fmt.Errorf("Password must contain an upper case letter")
+	}
+	if policy.RequireLower && !hasLower {
+		return fmt.Errorf("Password must contain a lower case letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		return fmt.Errorf("Password must contain a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		return fmt.Errorf("Password must contain a symbol")
+	}
+
+	if policy.denylist[strings.ToLower(password)] {
+		return fmt.Errorf("Password is too common, please choose another one")
+	}
+
+	return nil
+}
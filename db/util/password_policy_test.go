@@ -0,0 +1,62 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicyLength(t *testing.T) {
+	policy, err := NewPasswordPolicy(Config{PasswordMinLength: 10})
+	require.NoError(t, err)
+
+	require.Error(t, policy.Validate(RandomString(9)))
+	require.NoError(t, policy.Validate(RandomString(10)))
+	require.Error(t, policy.Validate(RandomString(maxPasswordLength+1)))
+}
+
+func TestPasswordPolicyMinLengthFloor(t *testing.T) {
+	policy, err := NewPasswordPolicy(Config{})
+	require.NoError(t, err)
+	require.Equal(t, minPasswordLength, policy.MinLength)
+
+	_, err = NewPasswordPolicy(Config{PasswordMinLength: maxPasswordLength + 1})
+	require.Error(t, err)
+}
+
+func TestPasswordPolicyCharacterClasses(t *testing.T) {
+	policy, err := NewPasswordPolicy(Config{
+		PasswordRequireUpper:  true,
+		PasswordRequireLower:  true,
+		PasswordRequireDigit:  true,
+		PasswordRequireSymbol: true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, policy.Validate("Sunny-Day-42"))
+	require.Error(t, policy.Validate("sunny-day-42"))
+	require.Error(t, policy.Validate("SUNNY-DAY-42"))
+	require.Error(t, policy.Validate("Sunny-Day-xy"))
+	require.Error(t, policy.Validate("SunnyDay42"))
+}
+
+func TestPasswordPolicyDenylist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+	content := strings.Join([]string{"# comment", "", "password123", "Letmein2022"}, "\n")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	policy, err := NewPasswordPolicy(Config{PasswordDenylistPath: path})
+	require.NoError(t, err)
+
+	require.Error(t, policy.Validate("password123"))
+	require.Error(t, policy.Validate("PASSWORD123"))
+	require.Error(t, policy.Validate("letmein2022"))
+	require.NoError(t, policy.Validate("correct horse battery"))
+	require.NoError(t, policy.Validate("# comment"))
+
+	_, err = NewPasswordPolicy(Config{PasswordDenylistPath: filepath.Join(t.TempDir(), "missing.txt")})
+	require.Error(t, err)
+}
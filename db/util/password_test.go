@@ -3,6 +3,7 @@ package util
 import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 	"testing"
 )
 
@@ -43,3 +44,64 @@ func TestDoubleHashing(t *testing.T) {
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword1, hashedPassword2)
 }
+
+func TestArgon2idHashFormat(t *testing.T) {
+	hashedPassword, err := HashPassword(RandomString(6))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hashedPassword, "$argon2id$v=19$m=19456,t=2,p=1$"))
+}
+
+func TestValidBcryptPassword(t *testing.T) {
+	password := RandomString(6)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	require.NoError(t, ValidPassword(password, string(hashedPassword)))
+	require.ErrorIs(t, ValidPassword(RandomString(6), string(hashedPassword)), ErrMismatchedPassword)
+}
+
+func TestInvalidPasswordHash(t *testing.T) {
+	require.Error(t, ValidPassword("secret", "$argon2id$v=19$m=19456$broken"))
+	require.Error(t, ValidPassword("secret", "not a hash"))
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(Config{})
+	require.NoError(t, err)
+	require.Equal(t, *DefaultPasswordHasher, *hasher)
+
+	hasher, err = NewPasswordHasher(Config{PasswordHashAlgorithm: BcryptAlgorithm, PasswordBcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+	require.Equal(t, BcryptAlgorithm, hasher.Algorithm)
+	require.Equal(t, bcrypt.MinCost, hasher.BcryptCost)
+
+	_, err = NewPasswordHasher(Config{PasswordHashAlgorithm: "md5"})
+	require.Error(t, err)
+
+	_, err = NewPasswordHasher(Config{PasswordHashAlgorithm: BcryptAlgorithm, PasswordBcryptCost: bcrypt.MaxCost + 1})
+	require.Error(t, err)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	password := RandomString(6)
+
+	bcryptHasher, err := NewPasswordHasher(Config{PasswordHashAlgorithm: BcryptAlgorithm, PasswordBcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+	bcryptHash, err := bcryptHasher.Hash(password)
+	require.NoError(t, err)
+	require.NoError(t, ValidPassword(password, bcryptHash))
+	require.False(t, bcryptHasher.NeedsRehash(bcryptHash))
+
+	argon2Hash, err := HashPassword(password)
+	require.NoError(t, err)
+	require.False(t, DefaultPasswordHasher.NeedsRehash(argon2Hash))
+
+	// Switching algorithm or raising the cost upgrades existing hashes
+	require.True(t, DefaultPasswordHasher.NeedsRehash(bcryptHash))
+	require.True(t, bcryptHasher.NeedsRehash(argon2Hash))
+
+	strongerHasher, err := NewPasswordHasher(Config{PasswordArgon2Time: 3})
+	require.NoError(t, err)
+	require.True(t, strongerHasher.NeedsRehash(argon2Hash))
+}
@@ -39,7 +39,7 @@ func RandomBalance() int64 {
 }
 
 func RandomCurrency() string {
-	currencies := []string{"USD", "EUR", "CAD", "HKT", "GBP"}
+	currencies := []string{"USD", "EUR", "CAD", "HKD", "GBP"}
 	n := len(currencies)
 	return currencies[rand.Intn(n)]
 }
@@ -0,0 +1,16 @@
+package util
+
+const (
+	// Supported Role
+	CustomerRole = "customer"
+	TellerRole   = "teller"
+	AdminRole    = "admin"
+)
+
+func IsSupportedRole(role string) bool {
+	switch role {
+	case CustomerRole, TellerRole, AdminRole:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule returns the next occurrence strictly after the given time
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+var errEmptySchedule = errors.New("schedule cannot be empty")
+
+// Parses a recurring schedule, either a standard cron expression such as
+// "0 9 1 * *" or "@monthly", optionally prefixed with CRON_TZ=<zone>, or an
+// RRULE such as "FREQ=MONTHLY;BYMONTHDAY=-1". Cron expressions are in UTC
+// unless they name a zone.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, errEmptySchedule
+	}
+
+	if strings.HasPrefix(spec, "RRULE:") || strings.HasPrefix(spec, "FREQ=") {
+		return parseRRule(strings.TrimPrefix(spec, "RRULE:"))
+	}
+
+	// The cron parser would otherwise use the local time zone of the server
+	if !strings.HasPrefix(spec, "CRON_TZ=") && !strings.HasPrefix(spec, "TZ=") {
+		spec = "CRON_TZ=UTC " + spec
+	}
+
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// rruleSchedule supports the subset of RFC 5545 recurrence rules that
+// standing orders need. Occurrences keep the time of day of the previous one.
+type rruleSchedule struct {
+	freq     string
+	interval int
+	// Day of the month for monthly rules, negative counts from the end and
+	// zero keeps the day of the previous occurrence. AnchorSchedule fills it
+	// in so a day clamped to the end of a short month is not carried over.
+	monthDay int
+}
+
+// Pins a monthly RRULE without BYMONTHDAY to the day of its first occurrence,
+// so that a schedule starting on the 31st keeps paying on the last day of
+// shorter months and returns to the 31st after them. Other schedules are
+// returned as they are.
+func AnchorSchedule(spec string, first time.Time) string {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return spec
+	}
+
+	rule, ok := schedule.(rruleSchedule)
+	if !ok || rule.freq != "MONTHLY" || rule.monthDay != 0 {
+		return spec
+	}
+	return fmt.Sprintf("%s;BYMONTHDAY=%d", strings.TrimSpace(spec), first.Day())
+}
+
+func parseRRule(rule string) (Schedule, error) {
+	schedule := rruleSchedule{interval: 1}
+
+	for _, part := range strings.Split(rule, ";") {
+		name, value, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid RRULE part %q", part)
+		}
+
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			switch value = strings.ToUpper(value); value {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				schedule.freq = value
+			default:
+				return nil, fmt.Errorf("unsupported RRULE frequency %q", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid RRULE interval %q", value)
+			}
+			schedule.interval = interval
+		case "BYMONTHDAY":
+			monthDay, err := strconv.Atoi(value)
+			if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+				return nil, fmt.Errorf("invalid RRULE month day %q", value)
+			}
+			schedule.monthDay = monthDay
+		default:
+			// COUNT and UNTIL are given as the max occurrences and end date of the order
+			return nil, fmt.Errorf("unsupported RRULE part %q", name)
+		}
+	}
+
+	if schedule.freq == "" {
+		return nil, errors.New("RRULE must have a FREQ")
+	}
+	if schedule.monthDay != 0 && schedule.freq != "MONTHLY" {
+		return nil, errors.New("RRULE BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	return schedule, nil
+}
+
+func (schedule rruleSchedule) Next(after time.Time) time.Time {
+	switch schedule.freq {
+	case "DAILY":
+		return after.AddDate(0, 0, schedule.interval)
+	case "WEEKLY":
+		return after.AddDate(0, 0, 7*schedule.interval)
+	case "YEARLY":
+		return after.AddDate(schedule.interval, 0, 0)
+	}
+
+	// Months differ in length, so the day is clamped to the end of the month
+	// instead of overflowing into the next one like AddDate does
+	year, month, day := after.Date()
+	first := time.Date(year, month+time.Month(schedule.interval), 1,
+		after.Hour(), after.Minute(), after.Second(), after.Nanosecond(), after.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+
+	if schedule.monthDay > 0 {
+		day = schedule.monthDay
+	} else if schedule.monthDay < 0 {
+		day = lastDay + schedule.monthDay + 1
+	}
+	if day > lastDay {
+		day = lastDay
+	}
+	if day < 1 {
+		day = 1
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,159 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	after := time.Date(2024, time.January, 31, 9, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		spec     string
+		expected []time.Time
+		err      bool
+	}{
+		{
+			name: "Cron",
+			spec: "0 9 1 * *",
+			expected: []time.Time{
+				time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Cron Descriptor",
+			spec: "@daily",
+			expected: []time.Time{
+				time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Cron With Time Zone",
+			spec: "CRON_TZ=Asia/Hong_Kong 0 9 1 * *",
+			expected: []time.Time{
+				time.Date(2024, time.February, 1, 1, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 1, 1, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Daily RRULE",
+			spec: "FREQ=DAILY;INTERVAL=2",
+			expected: []time.Time{
+				time.Date(2024, time.February, 2, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.February, 4, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Weekly RRULE",
+			spec: "RRULE:FREQ=WEEKLY",
+			expected: []time.Time{
+				time.Date(2024, time.February, 7, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.February, 14, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Monthly RRULE Clamps To Month End",
+			spec: "FREQ=MONTHLY;BYMONTHDAY=31",
+			expected: []time.Time{
+				time.Date(2024, time.February, 29, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.March, 31, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.April, 30, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Monthly RRULE Last Day",
+			spec: "FREQ=MONTHLY;BYMONTHDAY=-1",
+			expected: []time.Time{
+				time.Date(2024, time.February, 29, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.March, 31, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Quarterly RRULE",
+			spec: "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1",
+			expected: []time.Time{
+				time.Date(2024, time.April, 1, 9, 30, 0, 0, time.UTC),
+				time.Date(2024, time.July, 1, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Empty",
+			spec: " ",
+			err:  true,
+		},
+		{
+			name: "Invalid Cron",
+			spec: "0 9 32 * *",
+			err:  true,
+		},
+		{
+			name: "RRULE Without FREQ",
+			spec: "INTERVAL=2",
+			err:  true,
+		},
+		{
+			name: "RRULE With COUNT",
+			spec: "FREQ=DAILY;COUNT=3",
+			err:  true,
+		},
+		{
+			name: "RRULE Month Day Needs Monthly",
+			spec: "FREQ=WEEKLY;BYMONTHDAY=1",
+			err:  true,
+		},
+		{
+			name: "RRULE Invalid Interval",
+			spec: "FREQ=DAILY;INTERVAL=0",
+			err:  true,
+		},
+	}
+
+	for i := range testCases {
+		testCase := testCases[i]
+
+		t.Run(testCase.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(testCase.spec)
+			if testCase.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			next := after
+			for _, expected := range testCase.expected {
+				next = schedule.Next(next)
+				require.Equal(t, expected, next)
+			}
+		})
+	}
+}
+
+func TestAnchorSchedule(t *testing.T) {
+	first := time.Date(2027, time.January, 31, 9, 30, 0, 0, time.UTC)
+
+	spec := AnchorSchedule("FREQ=MONTHLY", first)
+	require.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=31", spec)
+
+	schedule, err := ParseSchedule(spec)
+	require.NoError(t, err)
+
+	next := first
+	for _, expected := range []time.Time{
+		time.Date(2027, time.February, 28, 9, 30, 0, 0, time.UTC),
+		time.Date(2027, time.March, 31, 9, 30, 0, 0, time.UTC),
+		time.Date(2027, time.April, 30, 9, 30, 0, 0, time.UTC),
+	} {
+		next = schedule.Next(next)
+		require.Equal(t, expected, next)
+	}
+
+	// Only monthly rules without a day of their own are anchored
+	for _, spec := range []string{"", "0 9 1 * *", "FREQ=WEEKLY", "FREQ=MONTHLY;BYMONTHDAY=-1"} {
+		require.Equal(t, spec, AnchorSchedule(spec, first))
+	}
+}
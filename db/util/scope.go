@@ -0,0 +1,16 @@
+package util
+
+const (
+	// Supported API key scopes
+	AccountsReadScope      = "accounts:read"
+	AccountsWriteScope     = "accounts:write"
+	TransactionsWriteScope = "transactions:write"
+)
+
+func IsSupportedScope(scope string) bool {
+	switch scope {
+	case AccountsReadScope, AccountsWriteScope, TransactionsWriteScope:
+		return true
+	}
+	return false
+}
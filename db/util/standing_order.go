@@ -0,0 +1,16 @@
+package util
+
+const (
+	// Supported Standing Order Status
+	ActiveStandingOrderStatus    = "active"
+	SuspendedStandingOrderStatus = "suspended"
+	CompletedStandingOrderStatus = "completed"
+	CancelledStandingOrderStatus = "cancelled"
+)
+
+const (
+	// Outcomes of an attempt to pay a standing order occurrence
+	SucceededStandingOrderRun = "succeeded"
+	RetryingStandingOrderRun  = "retrying"
+	FailedStandingOrderRun    = "failed"
+)
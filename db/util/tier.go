@@ -0,0 +1,16 @@
+package util
+
+const (
+	// Supported User Tiers, each may have its own transfer limits
+	StandardTier = "standard"
+	PremiumTier  = "premium"
+	BusinessTier = "business"
+)
+
+func IsSupportedTier(tier string) bool {
+	switch tier {
+	case StandardTier, PremiumTier, BusinessTier:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package util
+
+const (
+	// Supported Transfer Batch Modes
+	AllOrNothingBatchMode = "all_or_nothing"
+	BestEffortBatchMode   = "best_effort"
+)
+
+const (
+	// Supported Transfer Batch Status
+	ProcessingBatchStatus         = "processing"
+	CompletedBatchStatus          = "completed"
+	PartiallyCompletedBatchStatus = "partially_completed"
+	FailedBatchStatus             = "failed"
+)
+
+const (
+	// Outcomes of a line of a transfer batch
+	SucceededBatchLine = "succeeded"
+	FailedBatchLine    = "failed"
+	SkippedBatchLine   = "skipped"
+)
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes emails to a writer instead of delivering them.
+// It is meant for local development and tests.
+type LogMailer struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	from   string
+}
+
+func NewLogMailer(writer io.Writer, from string) *LogMailer {
+	return &LogMailer{
+		writer: writer,
+		from:   from,
+	}
+}
+
+// Creates a log mailer that appends emails to the file at path
+func NewFileMailer(path string, from string) (*LogMailer, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open mail log: %w", err)
+	}
+	return NewLogMailer(file, from), nil
+}
+
+func (mailer *LogMailer) SendEmail(ctx context.Context, email Email) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	_, err := mailer.writer.Write(buildMessage(mailer.from, email, time.Now()))
+	if err != nil {
+		return fmt.Errorf("Failed to write email: %w", err)
+	}
+	_, err = io.WriteString(mailer.writer, "\r\n")
+	return err
+}
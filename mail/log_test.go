@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"simplebank/db/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMailer(t *testing.T) {
+	var buffer bytes.Buffer
+	mailer := NewLogMailer(&buffer, "bank@example.com")
+
+	err := mailer.SendEmail(context.Background(), Email{
+		To:      []string{"user@example.com"},
+		Subject: "Hello",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+
+	message := buffer.String()
+	require.Contains(t, message, "From: bank@example.com\r\n")
+	require.Contains(t, message, "To: user@example.com\r\n")
+	require.Contains(t, message, "Subject: Hello\r\n")
+	require.Contains(t, message, "\r\n\r\nline one\r\nline two\r\n")
+}
+
+func TestFileMailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+
+	mailer, err := NewFileMailer(path, "bank@example.com")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		err = mailer.SendEmail(context.Background(), Email{
+			To:      []string{"user@example.com"},
+			Subject: "Hello",
+			Body:    "body",
+		})
+		require.NoError(t, err)
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, bytes.Count(data, []byte("Subject: Hello")))
+}
+
+func TestNewMailer(t *testing.T) {
+	mailer, err := NewMailer(util.Config{MailDriver: DriverLog})
+	require.NoError(t, err)
+	require.IsType(t, &LogMailer{}, mailer)
+
+	mailer, err = NewMailer(util.Config{MailDriver: DriverSMTP, SMTPHost: "localhost", SMTPPort: 25})
+	require.NoError(t, err)
+	require.IsType(t, &SMTPMailer{}, mailer)
+
+	_, err = NewMailer(util.Config{MailDriver: "pigeon"})
+	require.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"simplebank/db/util"
+)
+
+// Supported values of MAIL_DRIVER
+const (
+	DriverSMTP = "smtp"
+	DriverLog  = "log"
+)
+
+type Email struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails to users, e.g. password reset links
+type Mailer interface {
+	SendEmail(ctx context.Context, email Email) error
+}
+
+// Creates the mailer selected by MAIL_DRIVER. The log driver writes emails to
+// MAIL_LOG_PATH, or to stdout when no path is configured.
+func NewMailer(config util.Config) (Mailer, error) {
+	switch config.MailDriver {
+	case DriverSMTP:
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom), nil
+	case DriverLog, "":
+		if config.MailLogPath == "" {
+			return NewLogMailer(os.Stdout, config.MailFrom), nil
+		}
+		return NewFileMailer(config.MailLogPath, config.MailFrom)
+	}
+	return nil, fmt.Errorf("Unsupported mail driver: %s", config.MailDriver)
+}
@@ -4,8 +4,10 @@ import (
 	"database/sql"
 	"log"
 	"simplebank/api"
+	"simplebank/cronjob"
 	db "simplebank/db/sqlc"
 	"simplebank/db/util"
+	"time"
 
 	_ "github.com/lib/pq"
 )
@@ -28,6 +30,39 @@ func main() {
 	}
 
 	store := db.NewStore(connection)
+
+	// marking accounts without activity as dormant every day at 0300 UTC
+	if config.AccountDormancyPeriod > 0 {
+		go cronjob.StartCronJob("0 3 * * *", &cronjob.DormantAccountJob{
+			Store:            store,
+			InactivityPeriod: config.AccountDormancyPeriod,
+		}, time.UTC)
+	}
+
+	// checking balances against records every day, by default at 0330 UTC
+	if config.ReconciliationSchedule != "" {
+		go cronjob.StartCronJob(config.ReconciliationSchedule, &cronjob.ReconciliationJob{
+			Store:          store,
+			FreezeAccounts: config.ReconciliationFreeze,
+		}, time.UTC)
+	}
+
+	// paying due standing orders, by default every minute
+	if config.StandingOrderSchedule != "" {
+		go cronjob.StartCronJob(config.StandingOrderSchedule, &cronjob.StandingOrderJob{
+			Store:                store,
+			FXSpread:             config.FXSpread,
+			RetryDelay:           config.StandingOrderRetryDelay,
+			MaxAttempts:          config.StandingOrderAttempts,
+			MaxInsufficientFunds: config.StandingOrderNSFLimit,
+		}, time.UTC)
+	}
+
+	// removing expired idempotency keys every hour
+	go cronjob.StartCronJob("0 * * * *", &cronjob.IdempotencyKeyCleanupJob{
+		Store: store,
+	}, time.UTC)
+
 	server, err := api.NewServer(config, store)
 	if err != nil {
 		log.Fatal("Cannot create server: ", err)
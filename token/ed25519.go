@@ -0,0 +1,130 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// PublicKey is an Ed25519 verification key identified by the key ID carried in tokens
+type PublicKey struct {
+	ID  string
+	Key ed25519.PublicKey
+}
+
+// JSONWebKey describes a public key in RFC 8037 (OKP) form
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg,omitempty"`
+}
+
+// PublicKeyMaker is a Maker whose tokens can be verified with public keys alone
+type PublicKeyMaker interface {
+	Maker
+	// Keys that tokens of this maker may currently be signed with, for publishing as a JWKS
+	PublicKeys() []JSONWebKey
+}
+
+// Parses a base64 encoded 32 byte Ed25519 seed into a private key
+func ParseEd25519PrivateKey(seed string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key encoding: %w", err)
+	}
+	if len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key size")
+	}
+	return ed25519.NewKeyFromSeed(raw), nil
+}
+
+// Parses public keys written as comma separated kid:base64 pairs
+func ParseEd25519PublicKeys(keyList string) ([]PublicKey, error) {
+	var keys []PublicKey
+	for _, entry := range strings.Split(keyList, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.SplitN(entry, ":", 2)
+		if len(fields) != 2 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid key entry %q, expected kid:key", entry)
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key for key %q", fields[0])
+		}
+		keys = append(keys, PublicKey{ID: fields[0], Key: ed25519.PublicKey(raw)})
+	}
+	return keys, nil
+}
+
+// ed25519KeyRing signs with the primary private key and verifies with its
+// public key or any previous public key that has not been retired yet
+type ed25519KeyRing struct {
+	keyID      string
+	privateKey ed25519.PrivateKey
+	publicKeys []PublicKey
+}
+
+func newEd25519KeyRing(keyID string, privateKey ed25519.PrivateKey, previous []PublicKey) (*ed25519KeyRing, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key size")
+	}
+
+	primary := PublicKey{ID: keyID, Key: privateKey.Public().(ed25519.PublicKey)}
+	ring := &ed25519KeyRing{
+		keyID:      keyID,
+		privateKey: privateKey,
+		publicKeys: []PublicKey{primary},
+	}
+
+	seen := map[string]bool{keyID: true}
+	for _, key := range previous {
+		if key.ID == "" {
+			return nil, fmt.Errorf("previous keys must have a key ID")
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+		if len(key.Key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key size for key %q", key.ID)
+		}
+		seen[key.ID] = true
+		ring.publicKeys = append(ring.publicKeys, key)
+	}
+
+	return ring, nil
+}
+
+// Returns the keys to try for a token carrying the key ID, which may be empty
+func (ring *ed25519KeyRing) verificationKeys(keyID string) []ed25519.PublicKey {
+	var keys []ed25519.PublicKey
+	for _, key := range ring.publicKeys {
+		if keyID == "" || key.ID == keyID {
+			keys = append(keys, key.Key)
+		}
+	}
+	return keys
+}
+
+func (ring *ed25519KeyRing) jsonWebKeys(algorithm string) []JSONWebKey {
+	keys := make([]JSONWebKey, len(ring.publicKeys))
+	for i, key := range ring.publicKeys {
+		keys[i] = JSONWebKey{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.Key),
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: algorithm,
+		}
+	}
+	return keys
+}
@@ -0,0 +1,128 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"github.com/dgrijalva/jwt-go"
+	"time"
+)
+
+// SigningMethodEdDSA implements the RFC 8037 EdDSA algorithm with Ed25519 keys,
+// which jwt-go v3 does not ship with
+type SigningMethodEdDSA struct{}
+
+var SigningMethodEd25519 = &SigningMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEd25519.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEd25519
+	})
+}
+
+func (method *SigningMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+func (method *SigningMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (method *SigningMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+// JWTEdDSAMaker signs JWTs with an Ed25519 private key so that other services
+// can verify them with the public key only
+type JWTEdDSAMaker struct {
+	keys *ed25519KeyRing
+}
+
+func NewJWTEdDSAMaker(keyID string, privateKey ed25519.PrivateKey, previous ...PublicKey) (PublicKeyMaker, error) {
+	keys, err := newEd25519KeyRing(keyID, privateKey, previous)
+	if err != nil {
+		return nil, err
+	}
+	return &JWTEdDSAMaker{keys}, nil
+}
+
+func (maker *JWTEdDSAMaker) CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(SigningMethodEd25519, payload)
+	if maker.keys.keyID != "" {
+		jwtToken.Header["kid"] = maker.keys.keyID
+	}
+
+	token, err := jwtToken.SignedString(maker.keys.privateKey)
+	return token, payload, err
+}
+
+func (maker *JWTEdDSAMaker) VerifyToken(token string) (*Payload, error) {
+	for _, publicKey := range maker.keys.verificationKeys(jwtKeyID(token)) {
+		payload, err := maker.verify(token, publicKey)
+		if err != ErrInvalidToken {
+			return payload, err
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+func (maker *JWTEdDSAMaker) PublicKeys() []JSONWebKey {
+	return maker.keys.jsonWebKeys(SigningMethodEd25519.Alg())
+}
+
+// Reads the kid header without verifying the token to pick the keys to try
+func jwtKeyID(token string) string {
+	unverified, _, err := new(jwt.Parser).ParseUnverified(token, &Payload{})
+	if err != nil {
+		return ""
+	}
+	keyID, _ := unverified.Header["kid"].(string)
+	return keyID
+}
+
+func (maker *JWTEdDSAMaker) verify(token string, publicKey ed25519.PublicKey) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		_, ok := token.Method.(*SigningMethodEdDSA)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return publicKey, nil
+	}
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verification, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(verification.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,132 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+	"simplebank/db/util"
+	"testing"
+	"time"
+)
+
+func randomEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func TestJWTEdDSAMaker(t *testing.T) {
+	maker, err := NewJWTEdDSAMaker("2022-01", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	username := util.RandomUsername()
+	role := util.CustomerRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, role, AccessToken, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+	require.Equal(t, "2022-01", jwtKeyID(token))
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, AccessToken, payload.Type)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestExpiredJWTEdDSAToken(t *testing.T) {
+	maker, err := NewJWTEdDSAMaker("2022-01", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.Error(t, err)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTEdDSATokenAlgNone(t *testing.T) {
+	payload, err := NewPayload(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
+	token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	maker, err := NewJWTEdDSAMaker("2022-01", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestJWTEdDSAMakerRejectsSymmetricToken(t *testing.T) {
+	// A token signed with HS256 must not be accepted, whatever the key bytes
+	hmacMaker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := hmacMaker.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	maker, err := NewJWTEdDSAMaker("2022-01", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestJWTEdDSAMakerKeyRotation(t *testing.T) {
+	oldKey := randomEd25519Key(t)
+	oldMaker, err := NewJWTEdDSAMaker("old", oldKey)
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	oldPublicKey := PublicKey{ID: "old", Key: oldKey.Public().(ed25519.PublicKey)}
+	rotatedMaker, err := NewJWTEdDSAMaker("new", randomEd25519Key(t), oldPublicKey)
+	require.NoError(t, err)
+
+	_, err = rotatedMaker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Len(t, rotatedMaker.PublicKeys(), 2)
+
+	retiredMaker, err := NewJWTEdDSAMaker("new", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	_, err = retiredMaker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestJWTEdDSAMakerPublicKeys(t *testing.T) {
+	privateKey := randomEd25519Key(t)
+	maker, err := NewJWTEdDSAMaker("2022-01", privateKey)
+	require.NoError(t, err)
+
+	keys := maker.PublicKeys()
+	require.Len(t, keys, 1)
+	require.Equal(t, "OKP", keys[0].KeyType)
+	require.Equal(t, "Ed25519", keys[0].Curve)
+	require.Equal(t, "EdDSA", keys[0].Algorithm)
+	require.Equal(t, "2022-01", keys[0].KeyID)
+
+	publicKey, err := jwt.DecodeSegment(keys[0].X)
+	require.NoError(t, err)
+	require.Equal(t, []byte(privateKey.Public().(ed25519.PublicKey)), publicKey)
+}
@@ -3,6 +3,6 @@ package token
 import "time"
 
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string, error)
+	CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error)
 	VerifyToken(token string) (*Payload, error)
 }
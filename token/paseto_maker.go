@@ -4,39 +4,104 @@ import (
 	"fmt"
 	"github.com/o1egl/paseto"
 	"golang.org/x/crypto/chacha20poly1305"
+	"strings"
 	"time"
 )
 
+// PasetoKey is a symmetric key identified by the key ID written to the token footer
+type PasetoKey struct {
+	ID  string
+	Key []byte
+}
+
+type pasetoFooter struct {
+	KeyID string `json:"kid,omitempty"`
+}
+
+// PasetoMaker encrypts tokens with the primary key and decrypts them with
+// whichever key of the ring the token footer names. Rotating keys means
+// promoting a new primary key and keeping the old one as a previous key until
+// every token it encrypted has expired, then retiring it.
 type PasetoMaker struct {
-	paseto       *paseto.V2
-	symmetricKey []byte
+	paseto  *paseto.V2
+	primary PasetoKey
+	keys    map[string][]byte
+	// Keys in the order they are tried for tokens without a key ID
+	ordered [][]byte
 }
 
+// Creates a maker with a single key and no key ID
 func NewPasetoMaker(symmetricKey string) (Maker, error) {
-	if len(symmetricKey) != chacha20poly1305.KeySize {
-		return nil, fmt.Errorf("invalid key size")
-	}
+	return NewPasetoKeyRingMaker(PasetoKey{Key: []byte(symmetricKey)})
+}
 
+// Creates a maker that encrypts with the primary key and still accepts tokens
+// encrypted with any of the previous keys
+func NewPasetoKeyRingMaker(primary PasetoKey, previous ...PasetoKey) (Maker, error) {
 	maker := &PasetoMaker{
-		paseto:       paseto.NewV2(),
-		symmetricKey: []byte(symmetricKey),
+		paseto:  paseto.NewV2(),
+		primary: primary,
+		keys:    make(map[string][]byte),
+	}
+
+	for i, key := range append([]PasetoKey{primary}, previous...) {
+		if len(key.Key) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("invalid key size for key %q", key.ID)
+		}
+		if i > 0 && key.ID == "" {
+			return nil, fmt.Errorf("previous keys must have a key ID")
+		}
+		if _, ok := maker.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key ID %q", key.ID)
+		}
+
+		maker.keys[key.ID] = key.Key
+		maker.ordered = append(maker.ordered, key.Key)
 	}
 
 	return maker, nil
 }
 
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+// Parses previous keys written as comma separated kid:key pairs, e.g. "2022-01:<32 chars>,2021-12:<32 chars>"
+func ParsePasetoKeys(keyList string) ([]PasetoKey, error) {
+	var keys []PasetoKey
+	for _, entry := range strings.Split(keyList, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.SplitN(entry, ":", 2)
+		if len(fields) != 2 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid key entry %q, expected kid:key", entry)
+		}
+		keys = append(keys, PasetoKey{ID: fields[0], Key: []byte(fields[1])})
+	}
+	return keys, nil
+}
+
+func (maker *PasetoMaker) CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
 	if err != nil {
-		return "", err
+		return "", payload, err
+	}
+
+	var footer interface{}
+	if maker.primary.ID != "" {
+		footer = pasetoFooter{KeyID: maker.primary.ID}
 	}
-	return maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
+
+	token, err := maker.paseto.Encrypt(maker.primary.Key, payload, footer)
+	return token, payload, err
 }
 
 func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
-	payload := &Payload{}
+	var footer pasetoFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
 
-	err := maker.paseto.Decrypt(token, maker.symmetricKey, payload, nil)
+	payload, err := maker.decrypt(token, footer.KeyID)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
@@ -48,3 +113,26 @@ func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 
 	return payload, nil
 }
+
+// Decrypts with the key named in the footer. Tokens issued before key IDs were
+// introduced carry none, so every key in the ring is tried for them.
+func (maker *PasetoMaker) decrypt(token string, keyID string) (*Payload, error) {
+	candidates := maker.ordered
+	if keyID != "" {
+		key, ok := maker.keys[keyID]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		candidates = [][]byte{key}
+	}
+
+	var err error
+	for _, key := range candidates {
+		payload := &Payload{}
+		err = maker.paseto.Decrypt(token, key, payload, nil)
+		if err == nil {
+			return payload, nil
+		}
+	}
+	return nil, err
+}
@@ -2,6 +2,7 @@ package token
 
 import (
 	"github.com/dgrijalva/jwt-go"
+	"github.com/o1egl/paseto"
 	"github.com/stretchr/testify/require"
 	"simplebank/db/util"
 	"testing"
@@ -13,20 +14,24 @@ func TestPasetoMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandomUsername()
+	role := util.CustomerRole
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, AccessToken, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, AccessToken, payload.Type)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -36,20 +41,22 @@ func TestExpiredPasetoToken(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandomUsername()
+	role := util.CustomerRole
 	duration := time.Minute
 
-	token, err := maker.CreateToken(username, -duration)
+	token, payload, err := maker.CreateToken(username, role, AccessToken, -duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token)
 	require.Error(t, err)
 	require.EqualError(t, err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
 }
 
 func TestInvalidPasetoToken(t *testing.T) {
-	payload, err := NewPayload(util.RandomUsername(), time.Minute)
+	payload, err := NewPayload(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
 	require.NoError(t, err)
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
@@ -64,3 +71,116 @@ func TestInvalidPasetoToken(t *testing.T) {
 	require.Error(t, err, ErrInvalidToken)
 	require.Nil(t, payload)
 }
+
+func TestPasetoMakerKeyRotation(t *testing.T) {
+	oldKey := PasetoKey{ID: "2022-01", Key: []byte(util.RandomString(32))}
+	newKey := PasetoKey{ID: "2022-02", Key: []byte(util.RandomString(32))}
+
+	oldMaker, err := NewPasetoKeyRingMaker(oldKey)
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	var footer pasetoFooter
+	err = paseto.ParseFooter(oldToken, &footer)
+	require.NoError(t, err)
+	require.Equal(t, oldKey.ID, footer.KeyID)
+
+	// After rotation new tokens use the new key and old tokens are still accepted
+	rotatedMaker, err := NewPasetoKeyRingMaker(newKey, oldKey)
+	require.NoError(t, err)
+
+	_, err = rotatedMaker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	newToken, _, err := rotatedMaker.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	err = paseto.ParseFooter(newToken, &footer)
+	require.NoError(t, err)
+	require.Equal(t, newKey.ID, footer.KeyID)
+
+	_, err = oldMaker.VerifyToken(newToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// Once the old key is retired its tokens are rejected
+	retiredMaker, err := NewPasetoKeyRingMaker(newKey)
+	require.NoError(t, err)
+
+	_, err = retiredMaker.VerifyToken(newToken)
+	require.NoError(t, err)
+
+	_, err = retiredMaker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestPasetoMakerTokenWithoutKeyID(t *testing.T) {
+	symmetricKey := util.RandomString(32)
+
+	legacyMaker, err := NewPasetoMaker(symmetricKey)
+	require.NoError(t, err)
+
+	legacyToken, _, err := legacyMaker.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	// Tokens issued before key IDs were configured are tried against every key
+	maker, err := NewPasetoKeyRingMaker(
+		PasetoKey{ID: "2022-02", Key: []byte(util.RandomString(32))},
+		PasetoKey{ID: "2022-01", Key: []byte(symmetricKey)},
+	)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(legacyToken)
+	require.NoError(t, err)
+}
+
+func TestPasetoMakerUnknownKeyID(t *testing.T) {
+	maker1, err := NewPasetoKeyRingMaker(PasetoKey{ID: "a", Key: []byte(util.RandomString(32))})
+	require.NoError(t, err)
+
+	token, _, err := maker1.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	maker2, err := NewPasetoKeyRingMaker(PasetoKey{ID: "b", Key: []byte(util.RandomString(32))})
+	require.NoError(t, err)
+
+	payload, err := maker2.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestNewPasetoKeyRingMakerInvalidKeys(t *testing.T) {
+	key := PasetoKey{ID: "a", Key: []byte(util.RandomString(32))}
+
+	_, err := NewPasetoKeyRingMaker(PasetoKey{ID: "a", Key: []byte(util.RandomString(16))})
+	require.Error(t, err)
+
+	_, err = NewPasetoKeyRingMaker(key, PasetoKey{ID: "a", Key: []byte(util.RandomString(32))})
+	require.Error(t, err)
+
+	_, err = NewPasetoKeyRingMaker(key, PasetoKey{Key: []byte(util.RandomString(32))})
+	require.Error(t, err)
+}
+
+func TestParsePasetoKeys(t *testing.T) {
+	key1 := util.RandomString(32)
+	key2 := util.RandomString(32)
+
+	keys, err := ParsePasetoKeys("2022-01:" + key1 + ", 2021-12:" + key2)
+	require.NoError(t, err)
+	require.Equal(t, []PasetoKey{
+		{ID: "2022-01", Key: []byte(key1)},
+		{ID: "2021-12", Key: []byte(key2)},
+	}, keys)
+
+	keys, err = ParsePasetoKeys("")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	_, err = ParsePasetoKeys(key1)
+	require.Error(t, err)
+
+	_, err = ParsePasetoKeys(":" + key1)
+	require.Error(t, err)
+}
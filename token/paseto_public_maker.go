@@ -0,0 +1,69 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"github.com/o1egl/paseto"
+	"time"
+)
+
+// PasetoPublicMaker signs v2.public PASETO tokens with an Ed25519 private key
+// so that other services can verify them with the public key only
+type PasetoPublicMaker struct {
+	paseto *paseto.V2
+	keys   *ed25519KeyRing
+}
+
+func NewPasetoPublicMaker(keyID string, privateKey ed25519.PrivateKey, previous ...PublicKey) (PublicKeyMaker, error) {
+	keys, err := newEd25519KeyRing(keyID, privateKey, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	maker := &PasetoPublicMaker{
+		paseto: paseto.NewV2(),
+		keys:   keys,
+	}
+	return maker, nil
+}
+
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, tokenType string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	var footer interface{}
+	if maker.keys.keyID != "" {
+		footer = pasetoFooter{KeyID: maker.keys.keyID}
+	}
+
+	token, err := maker.paseto.Sign(maker.keys.privateKey, payload, footer)
+	return token, payload, err
+}
+
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	var footer pasetoFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	for _, publicKey := range maker.keys.verificationKeys(footer.KeyID) {
+		payload := &Payload{}
+		err := maker.paseto.Verify(token, publicKey, payload, nil)
+		if err != nil {
+			continue
+		}
+
+		err = payload.Valid()
+		if err != nil {
+			return nil, err
+		}
+		return payload, nil
+	}
+
+	return nil, ErrInvalidToken
+}
+
+func (maker *PasetoPublicMaker) PublicKeys() []JSONWebKey {
+	return maker.keys.jsonWebKeys("")
+}
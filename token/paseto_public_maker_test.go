@@ -0,0 +1,121 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"github.com/stretchr/testify/require"
+	"simplebank/db/util"
+	"testing"
+	"time"
+)
+
+func TestPasetoPublicMaker(t *testing.T) {
+	maker, err := NewPasetoPublicMaker("2022-01", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	username := util.RandomUsername()
+	role := util.CustomerRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, role, AccessToken, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+	require.Contains(t, token, "v2.public.")
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, AccessToken, payload.Type)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestExpiredPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker("2022-01", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicMakerWrongKey(t *testing.T) {
+	maker1, err := NewPasetoPublicMaker("2022-01", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	token, _, err := maker1.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	maker2, err := NewPasetoPublicMaker("2022-01", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	payload, err := maker2.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicMakerRejectsLocalToken(t *testing.T) {
+	localMaker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, _, err := localMaker.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker("", randomEd25519Key(t))
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestPasetoPublicMakerKeyRotation(t *testing.T) {
+	oldKey := randomEd25519Key(t)
+	oldMaker, err := NewPasetoPublicMaker("old", oldKey)
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(util.RandomUsername(), util.CustomerRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	oldPublicKey := PublicKey{ID: "old", Key: oldKey.Public().(ed25519.PublicKey)}
+	rotatedMaker, err := NewPasetoPublicMaker("new", randomEd25519Key(t), oldPublicKey)
+	require.NoError(t, err)
+
+	_, err = rotatedMaker.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	_, err = NewPasetoPublicMaker("new", randomEd25519Key(t), PublicKey{ID: "new", Key: oldPublicKey.Key})
+	require.Error(t, err)
+}
+
+func TestParseEd25519Keys(t *testing.T) {
+	privateKey := randomEd25519Key(t)
+	seed := base64.StdEncoding.EncodeToString(privateKey.Seed())
+
+	parsed, err := ParseEd25519PrivateKey(seed)
+	require.NoError(t, err)
+	require.Equal(t, privateKey, parsed)
+
+	_, err = ParseEd25519PrivateKey(base64.StdEncoding.EncodeToString([]byte("too short")))
+	require.Error(t, err)
+
+	_, err = ParseEd25519PrivateKey("not base64!")
+	require.Error(t, err)
+
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	keys, err := ParseEd25519PublicKeys("old:" + base64.StdEncoding.EncodeToString(publicKey))
+	require.NoError(t, err)
+	require.Equal(t, []PublicKey{{ID: "old", Key: publicKey}}, keys)
+
+	_, err = ParseEd25519PublicKeys("old:" + seed[:10])
+	require.Error(t, err)
+}